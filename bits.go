@@ -0,0 +1,112 @@
+package ringbuffer
+
+import "io"
+
+// BitWriter packs values of arbitrary bit width into a RingBuffer, most
+// significant bit first.
+// Bits are accumulated until a whole byte is completed, which is then
+// written with WriteByte, so the buffer only ever holds, and evicts, whole
+// bytes.
+// A BitWriter is not safe for concurrent use.
+type BitWriter struct {
+	w    io.ByteWriter
+	cur  byte
+	bits int
+}
+
+// NewBitWriter creates a new BitWriter writing into r.
+func NewBitWriter(r *RingBuffer) *BitWriter {
+	return &BitWriter{
+		w: r,
+	}
+}
+
+// WriteBits writes the lowest n bits of v, with n in the range [0, 64].
+// It returns the first error encountered writing the completed bytes.
+func (b *BitWriter) WriteBits(v uint64, n int) error {
+	for n > 0 {
+		take := 8 - b.bits
+		if take > n {
+			take = n
+		}
+
+		n -= take
+		b.cur = b.cur<<uint(take) | byte(v>>uint(n))&(1<<uint(take)-1)
+		b.bits += take
+
+		if b.bits == 8 {
+			if err := b.w.WriteByte(b.cur); err != nil {
+				return err
+			}
+			b.cur, b.bits = 0, 0
+		}
+	}
+	return nil
+}
+
+// Pending returns the number of bits written but not yet flushed into the
+// buffer, waiting for their byte to be completed.
+func (b *BitWriter) Pending() int {
+	return b.bits
+}
+
+// Pad completes the current byte with zeros and writes it, aligning the
+// writer to a byte boundary. It does nothing if already aligned.
+func (b *BitWriter) Pad() error {
+	if b.bits == 0 {
+		return nil
+	}
+	return b.WriteBits(0, 8-b.bits)
+}
+
+// BitReader reads values of arbitrary bit width, most significant bit first,
+// like they are written by BitWriter, e.g. from the content of a RingBuffer
+// wrapped in a bytes.Reader.
+type BitReader struct {
+	r    io.ByteReader
+	cur  byte
+	bits int
+}
+
+// NewBitReader creates a new BitReader reading from r.
+func NewBitReader(r io.ByteReader) *BitReader {
+	return &BitReader{
+		r: r,
+	}
+}
+
+// ReadBits reads n bits, with n in the range [0, 64], and returns them as
+// the lowest bits of the result.
+// It returns io.EOF if no bit is left, and io.ErrUnexpectedEOF if the input
+// ends in the middle of the value.
+func (b *BitReader) ReadBits(n int) (uint64, error) {
+	var v uint64
+	for read := 0; read < n; {
+		if b.bits == 0 {
+			c, err := b.r.ReadByte()
+			if err == io.EOF && read > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			if err != nil {
+				return 0, err
+			}
+			b.cur, b.bits = c, 8
+		}
+
+		take := n - read
+		if take > b.bits {
+			take = b.bits
+		}
+
+		b.bits -= take
+		v = v<<uint(take) | uint64(b.cur>>uint(b.bits))&(1<<uint(take)-1)
+		read += take
+	}
+	return v, nil
+}
+
+// Align discards the bits left in the current byte, so the next read starts
+// at a byte boundary, matching Pad.
+func (b *BitReader) Align() {
+	b.bits = 0
+}
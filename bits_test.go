@@ -0,0 +1,123 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestBitWriter(t *testing.T) {
+	t.Parallel()
+
+	type value struct {
+		v uint64
+		n int
+	}
+
+	tests := []struct {
+		name        string
+		maxSize     int
+		values      []value
+		wantBytes   []byte
+		wantPending int
+		skip        int
+		wantValues  []value
+	}{
+		{
+			name:        "nibbles",
+			maxSize:     8,
+			values:      []value{{0xa, 4}, {0xb, 4}, {0xc, 4}},
+			wantBytes:   []byte{0xab},
+			wantPending: 4,
+			wantValues:  []value{{0xa, 4}, {0xb, 4}, {0xc, 4}},
+		},
+		{
+			name:        "varying widths",
+			maxSize:     16,
+			values:      []value{{1, 1}, {5, 3}, {0x1ff, 9}, {0, 2}, {0x3fff, 14}, {0xdeadbeefcafe, 64}},
+			wantBytes:   []byte{0xdf, 0xf9, 0xff, 0xf8, 0x00, 0x06, 0xf5, 0x6d, 0xf7, 0x7e, 0x57},
+			wantPending: 5,
+			wantValues:  []value{{1, 1}, {5, 3}, {0x1ff, 9}, {0, 2}, {0x3fff, 14}, {0xdeadbeefcafe, 64}},
+		},
+		{
+			name:       "higher bits ignored",
+			maxSize:    8,
+			values:     []value{{0xff, 4}, {0x10, 4}},
+			wantBytes:  []byte{0xf0},
+			wantValues: []value{{0xf, 4}, {0, 4}},
+		},
+		{
+			name:    "ring evicting whole bytes",
+			maxSize: 2,
+			values: []value{
+				{0x1, 4}, {0x2, 4}, {0x3, 4}, {0x4, 4},
+				{0x5, 4}, {0x6, 4}, {0x7, 4}, {0x8, 4},
+			},
+			wantBytes:  []byte{0x56, 0x78},
+			wantValues: []value{{0x5, 4}, {0x6, 4}, {0x7, 4}, {0x8, 4}},
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(0, tt.maxSize)
+			w := NewBitWriter(r)
+			for _, v := range tt.values {
+				if err := w.WriteBits(v.v, v.n); err != nil {
+					t.Fatalf("WriteBits() error = %v", err)
+				}
+			}
+
+			if got := r.Bytes(); !bytes.Equal(got, tt.wantBytes) {
+				t.Errorf("Bytes() got = %x, want %x", got, tt.wantBytes)
+			}
+			if got := w.Pending(); got != tt.wantPending {
+				t.Errorf("Pending() got = %d, want %d", got, tt.wantPending)
+			}
+
+			if err := w.Pad(); err != nil {
+				t.Fatalf("Pad() error = %v", err)
+			}
+			if got := w.Pending(); got != 0 {
+				t.Errorf("Pending() after Pad() got = %d, want 0", got)
+			}
+
+			br := NewBitReader(bytes.NewReader(r.Bytes()))
+			var got []value
+			for _, v := range tt.wantValues {
+				x, err := br.ReadBits(v.n)
+				if err != nil {
+					t.Fatalf("ReadBits() error = %v", err)
+				}
+				got = append(got, value{x, v.n})
+			}
+			if !reflect.DeepEqual(got, tt.wantValues) {
+				t.Errorf("ReadBits() got = %x, want %x", got, tt.wantValues)
+			}
+		})
+	}
+}
+
+func TestBitReader_EOF(t *testing.T) {
+	t.Parallel()
+
+	br := NewBitReader(bytes.NewReader([]byte{0xab, 0xcd}))
+
+	if v, err := br.ReadBits(4); v != 0xa || err != nil {
+		t.Errorf("ReadBits(4) got = %x, %v, want a, nil", v, err)
+	}
+	br.Align()
+	if v, err := br.ReadBits(4); v != 0xc || err != nil {
+		t.Errorf("ReadBits(4) after Align() got = %x, %v, want c, nil", v, err)
+	}
+	if _, err := br.ReadBits(8); err != io.ErrUnexpectedEOF {
+		t.Errorf("ReadBits(8) error = %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	if _, err := br.ReadBits(1); err != io.EOF {
+		t.Errorf("ReadBits(1) error = %v, want %v", err, io.EOF)
+	}
+}
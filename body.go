@@ -0,0 +1,57 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// bodyPool recycles the snapshots of the content taken by Body.
+var bodyPool = sync.Pool{
+	New: func() interface{} {
+		return new([]byte)
+	},
+}
+
+// body is the io.ReadCloser returned by Body.
+type body struct {
+	snapshot *[]byte
+	reader   bytes.Reader
+}
+
+// Body returns an io.ReadCloser reading a snapshot of the content taken at
+// call time, e.g. to be used as the body of an HTTP response: it is not
+// affected by the following changes of the buffer.
+// Close releases the snapshot, which is recycled by the next calls to Body,
+// so the reader must not be used afterwards: Read returns ErrClosed.
+func (r *RingBuffer) Body() io.ReadCloser {
+	snapshot := bodyPool.Get().(*[]byte)
+	if cap(*snapshot) < r.Len() {
+		*snapshot = make([]byte, r.Len())
+	}
+	*snapshot = (*snapshot)[:r.Len()]
+	r.readAt(*snapshot, 0)
+
+	b := &body{snapshot: snapshot}
+	b.reader.Reset(*snapshot)
+	return b
+}
+
+// Read reads from the snapshot, like bytes.Reader.
+func (b *body) Read(p []byte) (int, error) {
+	if b.snapshot == nil {
+		return 0, ErrClosed
+	}
+	return b.reader.Read(p)
+}
+
+// Close releases the snapshot. Calling it more than once does nothing.
+func (b *body) Close() error {
+	if b.snapshot == nil {
+		return nil
+	}
+	b.reader.Reset(nil)
+	bodyPool.Put(b.snapshot)
+	b.snapshot = nil
+	return nil
+}
@@ -0,0 +1,87 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestRingBuffer_Body(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		limit int
+		want  string
+	}{
+		{name: "read fully", limit: -1, want: "cdefgh"},
+		{name: "closed early", limit: 2, want: "cd"},
+		{name: "closed before reading", limit: 0, want: ""},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(0, 6)
+			_, _ = r.Write([]byte("abcdefgh"))
+
+			body := r.Body()
+
+			// later writes must not change the snapshot
+			_, _ = r.Write([]byte("012345"))
+
+			var src io.Reader = body
+			if tt.limit >= 0 {
+				src = io.LimitReader(body, int64(tt.limit))
+			}
+			var got bytes.Buffer
+			if _, err := got.ReadFrom(src); err != nil {
+				t.Fatalf("ReadFrom() error = %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("Body() got = %q, want %q", got.String(), tt.want)
+			}
+
+			if err := body.Close(); err != nil {
+				t.Errorf("Close() error = %v", err)
+			}
+			if err := body.Close(); err != nil {
+				t.Errorf("Close() twice error = %v", err)
+			}
+			if n, err := body.Read(make([]byte, 4)); n != 0 || err != ErrClosed {
+				t.Errorf("Read() after Close got = %d, %v, want 0, %v", n, err, ErrClosed)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_Body_released(t *testing.T) {
+	r := NewRingBuffer(0, 64)
+	_, _ = r.Write(bytes.Repeat([]byte("x"), 64))
+
+	// the snapshot is released on Close and reused by the next Body
+	first := r.Body().(*body)
+	snapshot := first.snapshot
+	_ = first.Close()
+	if first.snapshot != nil {
+		t.Errorf("Close() kept the snapshot")
+	}
+
+	_, _ = r.Write([]byte("abc"))
+	next := r.Body().(*body)
+	defer next.Close()
+
+	// the pool can drop items at any time, so a reuse is not guaranteed,
+	// but a reused snapshot must hold the new content only
+	if next.snapshot == snapshot && string(*next.snapshot) != r.String() {
+		t.Errorf("reused snapshot got = %q, want %q", *next.snapshot, r.String())
+	}
+
+	var got bytes.Buffer
+	_, _ = got.ReadFrom(next)
+	if got.String() != r.String() {
+		t.Errorf("Body() got = %q, want %q", got.String(), r.String())
+	}
+}
@@ -0,0 +1,128 @@
+package ringbuffer
+
+import "io"
+
+// Builder accumulates many small writes and commits them into a RingBuffer
+// with a single Write, so the buffer evaluates the grow condition and copies
+// the data once per batch instead of once per write.
+// The pending content is written when it reaches the size of the Builder,
+// or when Flush is called: until then it is not visible in the buffer.
+// A Builder is not safe for concurrent use.
+type Builder struct {
+	r       *RingBuffer
+	size    int
+	pending []byte
+	closed  bool
+}
+
+// interfaces implemented by Builder
+var (
+	_ io.Writer       = (*Builder)(nil)
+	_ io.StringWriter = (*Builder)(nil)
+	_ io.Closer       = (*Builder)(nil)
+)
+
+// NewBuilder creates a new Builder writing into r in batches of size bytes.
+// A negative size is considered 0, so every write is flushed at once.
+func NewBuilder(r *RingBuffer, size int) *Builder {
+	if size < 0 {
+		size = 0
+	}
+	return &Builder{
+		r:       r,
+		size:    size,
+		pending: make([]byte, 0, size),
+	}
+}
+
+// NewBufferedWriter is NewBuilder for the callers looking for the
+// equivalent of a bufio.Writer: it returns a Builder writing into r in
+// batches of flushSize bytes, to be flushed with Flush or Close at the end.
+func NewBufferedWriter(r *RingBuffer, flushSize int) *Builder {
+	return NewBuilder(r, flushSize)
+}
+
+// WriteString appends s to the pending content, flushing it if it reaches
+// the size of the Builder. It returns len(s), and the error of the flush,
+// if any.
+// A string that the buffer would reject on its own, because of the
+// OverflowReject policy or of WithMaxWriteSize, is rejected at once with
+// the same error, and nothing is appended.
+// After Close, it returns ErrClosed.
+func (b *Builder) WriteString(s string) (int, error) {
+	if b.closed {
+		return 0, ErrClosed
+	}
+	if err := b.r.checkWriteSize(len(s)); err != nil {
+		return 0, err
+	}
+	b.pending = append(b.pending, s...)
+	return len(s), b.flushFull()
+}
+
+// Write appends p to the pending content, like WriteString.
+func (b *Builder) Write(p []byte) (int, error) {
+	if b.closed {
+		return 0, ErrClosed
+	}
+	if err := b.r.checkWriteSize(len(p)); err != nil {
+		return 0, err
+	}
+	b.pending = append(b.pending, p...)
+	return len(p), b.flushFull()
+}
+
+// Buffered returns the number of pending bytes, not yet written into the
+// buffer.
+func (b *Builder) Buffered() int {
+	return len(b.pending)
+}
+
+// Flush writes the pending content into the buffer with a single Write, or
+// with as few writes as the size limits of the buffer allow, so that the
+// batch is not rejected when the single writes were not.
+// If a write fails, the content not written yet is kept pending and the
+// error returned.
+func (b *Builder) Flush() error {
+	limit := b.r.writeLimit()
+	if limit <= 0 {
+		limit = len(b.pending)
+	}
+
+	for n := 0; n < len(b.pending); {
+		chunk := b.pending[n:]
+		if len(chunk) > limit {
+			chunk = chunk[:limit]
+		}
+		if _, err := b.r.Write(chunk); err != nil {
+			b.pending = b.pending[:copy(b.pending, b.pending[n:])]
+			return err
+		}
+		n += len(chunk)
+	}
+	b.pending = b.pending[:0]
+	return nil
+}
+
+// Close flushes the pending content, after which the Builder can't be
+// written anymore. The RingBuffer is not closed.
+// If the flush fails, the Builder is not closed and the error returned.
+func (b *Builder) Close() error {
+	if b.closed {
+		return nil
+	}
+	if err := b.Flush(); err != nil {
+		return err
+	}
+	b.closed = true
+	return nil
+}
+
+// flushFull flushes the pending content if it reached the size of the
+// Builder.
+func (b *Builder) flushFull() error {
+	if len(b.pending) < b.size {
+		return nil
+	}
+	return b.Flush()
+}
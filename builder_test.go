@@ -0,0 +1,240 @@
+package ringbuffer
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		maxSize     int
+		size        int
+		opts        []Option
+		writes      []string
+		wantPending int
+	}{
+		{
+			name:        "all pending",
+			maxSize:     64,
+			size:        16,
+			writes:      []string{"ab", "cd", "ef"},
+			wantPending: 6,
+		},
+		{
+			name:        "flushed on size",
+			maxSize:     64,
+			size:        4,
+			writes:      []string{"ab", "cd", "ef"},
+			wantPending: 2,
+		},
+		{
+			name:        "ring mode",
+			maxSize:     8,
+			size:        5,
+			writes:      []string{"abc", "def", "ghi", "jkl", "mno"},
+			wantPending: 3,
+		},
+		{
+			name:        "write larger than size",
+			maxSize:     64,
+			size:        2,
+			writes:      []string{"abcdef", "g"},
+			wantPending: 1,
+		},
+		{
+			name:        "negative size",
+			maxSize:     64,
+			size:        -1,
+			writes:      []string{"ab", "cd"},
+			wantPending: 0,
+		},
+		{
+			name:        "batch larger than the maximum size, rejecting",
+			maxSize:     4,
+			size:        8,
+			opts:        []Option{WithOverflowPolicy(OverflowReject)},
+			writes:      []string{"abc", "def"},
+			wantPending: 6,
+		},
+		{
+			name:        "batch larger than the maximum write size",
+			maxSize:     64,
+			size:        16,
+			opts:        []Option{WithMaxWriteSize(4)},
+			writes:      []string{"abc", "def", "ghi"},
+			wantPending: 9,
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			naive := NewRingBuffer(0, tt.maxSize, tt.opts...)
+			r := NewRingBuffer(0, tt.maxSize, tt.opts...)
+			b := NewBuilder(r, tt.size)
+			for _, s := range tt.writes {
+				_, _ = naive.WriteString(s)
+				if n, err := b.WriteString(s); n != len(s) || err != nil {
+					t.Fatalf("WriteString() got = %d, %v, want %d, nil", n, err, len(s))
+				}
+			}
+
+			if got := b.Buffered(); got != tt.wantPending {
+				t.Errorf("Buffered() got = %d, want %d", got, tt.wantPending)
+			}
+
+			if err := b.Flush(); err != nil {
+				t.Fatalf("Flush() error = %v", err)
+			}
+			if got, want := r.String(), naive.String(); got != want {
+				t.Errorf("String() got = %q, want %q", got, want)
+			}
+			if got, want := r.Written(), naive.Written(); got != want {
+				t.Errorf("Written() got = %d, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestBuilder_Flush_error(t *testing.T) {
+	t.Parallel()
+
+	r := NewRingBuffer(0, 8)
+	r.Freeze()
+	b := NewBuilder(r, 4)
+
+	if _, err := b.WriteString("abcd"); err != ErrFrozen {
+		t.Errorf("WriteString() error = %v, want %v", err, ErrFrozen)
+	}
+	if got := b.Buffered(); got != 4 {
+		t.Errorf("Buffered() got = %d, want %d", got, 4)
+	}
+
+	r.Unfreeze()
+	if err := b.Flush(); err != nil {
+		t.Errorf("Flush() error = %v", err)
+	}
+	if got := r.String(); got != "abcd" {
+		t.Errorf("String() got = %q, want %q", got, "abcd")
+	}
+}
+
+func TestBuilder_Write_tooLarge(t *testing.T) {
+	t.Parallel()
+
+	r := NewRingBuffer(0, 4, WithOverflowPolicy(OverflowReject))
+	b := NewBuilder(r, 8)
+
+	if n, err := b.WriteString("abcde"); n != 0 || !errors.Is(err, ErrRecordTooLarge) {
+		t.Errorf("WriteString() got = %d, %v, want 0, %v", n, err, ErrRecordTooLarge)
+	}
+	if got := b.Buffered(); got != 0 {
+		t.Errorf("Buffered() got = %d, want 0", got)
+	}
+	if err := b.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+}
+
+func TestNewBufferedWriter(t *testing.T) {
+	t.Parallel()
+
+	naive := NewRingBuffer(0, 1<<16)
+	r := NewRingBuffer(0, 1<<16)
+	w := NewBufferedWriter(r, 1024)
+
+	naiveGrows, grows := 0, 0
+	for i := 0; i < 1000; i++ {
+		line := "line " + strconv.Itoa(i) + "\n"
+
+		c := naive.Cap()
+		_, _ = naive.WriteString(line)
+		if naive.Cap() != c {
+			naiveGrows++
+		}
+
+		c = r.Cap()
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		if r.Cap() != c {
+			grows++
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if got, want := r.String(), naive.String(); got != want {
+		t.Errorf("String() got = %q, want %q", got, want)
+	}
+	if grows >= naiveGrows {
+		t.Errorf("grows got = %d, want fewer than the unbuffered %d", grows, naiveGrows)
+	}
+
+	if _, err := w.Write([]byte("x")); err != ErrClosed {
+		t.Errorf("Write() after Close error = %v, want %v", err, ErrClosed)
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("Close() twice error = %v", err)
+	}
+}
+
+func BenchmarkBuilder_WriteString(b *testing.B) {
+	lines := make([]string, 1000)
+	for i := range lines {
+		lines[i] = "line " + strconv.Itoa(i) + "\n"
+	}
+
+	// grows reports how many times the buffer reallocated its memory
+	grows := func(r *RingBuffer, write func(s string)) int {
+		n, last := 0, r.Cap()
+		for _, s := range lines {
+			write(s)
+			if c := r.Cap(); c != last {
+				n, last = n+1, c
+			}
+		}
+		return n
+	}
+
+	b.Run("WriteString", func(b *testing.B) {
+		b.ReportAllocs()
+		n := 0
+		for i := 0; i < b.N; i++ {
+			r := NewRingBuffer(0, 1<<20)
+			n += grows(r, func(s string) { _, _ = r.WriteString(s) })
+		}
+		b.ReportMetric(float64(n)/float64(b.N), "grows/op")
+	})
+
+	b.Run("Builder", func(b *testing.B) {
+		b.ReportAllocs()
+		n := 0
+		for i := 0; i < b.N; i++ {
+			r := NewRingBuffer(0, 1<<20)
+			w := NewBuilder(r, 4096)
+			n += grows(r, func(s string) { _, _ = w.WriteString(s) })
+			_ = w.Flush()
+		}
+		b.ReportMetric(float64(n)/float64(b.N), "grows/op")
+	})
+
+	b.Run("BufferedWriter", func(b *testing.B) {
+		b.ReportAllocs()
+		n := 0
+		for i := 0; i < b.N; i++ {
+			r := NewRingBuffer(0, 1<<20)
+			w := NewBufferedWriter(r, 4096)
+			n += grows(r, func(s string) { _, _ = w.Write([]byte(s)) })
+			_ = w.Close()
+		}
+		b.ReportMetric(float64(n)/float64(b.N), "grows/op")
+	})
+}
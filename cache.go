@@ -0,0 +1,17 @@
+package ringbuffer
+
+// WithStringCache enables the caching of the String result, useful for
+// buffers read much more often than written.
+// The cached string is returned until the content changes.
+func WithStringCache() Option {
+	return func(r *RingBuffer) {
+		r.stringCache = true
+	}
+}
+
+// invalidateString drops the cached String result, if any.
+// It must be called by every method changing the content.
+func (r *RingBuffer) invalidateString() {
+	r.cachedValid = false
+	r.cached = ""
+}
@@ -0,0 +1,118 @@
+package ringbuffer
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWithStringCache(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		mutate func(r *RingBuffer)
+		want   string
+	}{
+		{
+			name:   "no mutation",
+			mutate: func(r *RingBuffer) {},
+			want:   "bcde",
+		},
+		{
+			name:   "write",
+			mutate: func(r *RingBuffer) { _, _ = r.Write([]byte("f")) },
+			want:   "cdef",
+		},
+		{
+			name:   "empty write",
+			mutate: func(r *RingBuffer) { _, _ = r.Write(nil) },
+			want:   "bcde",
+		},
+		{
+			name:   "drain",
+			mutate: func(r *RingBuffer) { r.Drain(2) },
+			want:   "de",
+		},
+		{
+			name:   "read",
+			mutate: func(r *RingBuffer) { _, _ = r.Read(make([]byte, 1)) },
+			want:   "cde",
+		},
+		{
+			name:   "reset",
+			mutate: func(r *RingBuffer) { r.Reset() },
+			want:   "",
+		},
+		{
+			name:   "clear content",
+			mutate: func(r *RingBuffer) { r.ClearContent() },
+			want:   "",
+		},
+		{
+			name:   "close",
+			mutate: func(r *RingBuffer) { _ = r.Close() },
+			want:   "",
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(0, 4, WithStringCache())
+			_, _ = r.Write([]byte("abcde"))
+
+			if got := r.String(); got != "bcde" {
+				t.Errorf("String() got = %q, want %q", got, "bcde")
+			}
+			if !r.cachedValid {
+				t.Errorf("String() result not cached")
+			}
+
+			tt.mutate(r)
+
+			if got := r.String(); got != tt.want {
+				t.Errorf("String() got = %q, want %q", got, tt.want)
+			}
+			if got := r.string(); got != tt.want {
+				t.Errorf("string() got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithStringCache_MPSC(t *testing.T) {
+	t.Parallel()
+
+	const record = "0123456789"
+
+	m := NewMPSCRingBuffer(0, 10*len(record), WithStringCache())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				_, _ = m.Write([]byte(record))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				// whole records only, since writes are atomic
+				if s := m.String(); strings.Replace(s, record, "", -1) != "" {
+					t.Errorf("String() got = %q, not made of whole records", s)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got, want := m.String(), strings.Repeat(record, 10); got != want {
+		t.Errorf("String() got = %q, want %q", got, want)
+	}
+}
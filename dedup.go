@@ -0,0 +1,33 @@
+package ringbuffer
+
+import "bytes"
+
+// WithDedup enables the deduplication of consecutive identical writes.
+// The "last chunk" is the p of the last Write call that stored data: when a
+// Write receives exactly the same bytes, nothing is stored and the repeat
+// counter, returned by Repeats, is increased instead.
+// Empty writes are never considered repeats.
+func WithDedup() Option {
+	return func(r *RingBuffer) {
+		r.dedup = true
+	}
+}
+
+// Repeats returns how many writes have been skipped because identical to
+// the previous one. It is always 0 if WithDedup is not set.
+func (r *RingBuffer) Repeats() int {
+	return r.repeats
+}
+
+// isRepeat reports whether p is the same as the last chunk written.
+func (r *RingBuffer) isRepeat(p []byte) bool {
+	return len(p) > 0 && r.lastChunk != nil && bytes.Equal(p, r.lastChunk)
+}
+
+// recordChunk records p as the new last chunk. It is called only once p
+// has been stored, so that a failed write can be retried.
+func (r *RingBuffer) recordChunk(p []byte) {
+	if len(p) > 0 {
+		r.lastChunk = append(r.lastChunk[:0], p...)
+	}
+}
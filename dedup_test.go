@@ -0,0 +1,122 @@
+package ringbuffer
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWithDedup(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		writes      []string
+		wantString  string
+		wantWritten int
+		wantRepeats int
+	}{
+		{
+			name:        "repeated",
+			writes:      []string{"line\n", "line\n", "line\n"},
+			wantString:  "line\n",
+			wantWritten: 5,
+			wantRepeats: 2,
+		},
+		{
+			name:        "interleaved",
+			writes:      []string{"a\n", "b\n", "a\n", "a\n", "b\n", "b\n"},
+			wantString:  "a\nb\na\nb\n",
+			wantWritten: 8,
+			wantRepeats: 2,
+		},
+		{
+			name:        "empty writes",
+			writes:      []string{"", "", "a", ""},
+			wantString:  "a",
+			wantWritten: 1,
+			wantRepeats: 0,
+		},
+		{
+			name:        "prefix is not a repeat",
+			writes:      []string{"abc", "ab", "ab"},
+			wantString:  "abcab",
+			wantWritten: 5,
+			wantRepeats: 1,
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(0, 64, WithDedup())
+			for _, w := range tt.writes {
+				n, err := r.Write([]byte(w))
+				if err != nil || n != len(w) {
+					t.Fatalf("Write() got = %d, %v, want %d, nil", n, err, len(w))
+				}
+			}
+
+			if got := r.String(); got != tt.wantString {
+				t.Errorf("String() got = %q, want %q", got, tt.wantString)
+			}
+			if got := r.Written(); got != tt.wantWritten {
+				t.Errorf("Written() got = %d, want %d", got, tt.wantWritten)
+			}
+			if got := r.Repeats(); got != tt.wantRepeats {
+				t.Errorf("Repeats() got = %d, want %d", got, tt.wantRepeats)
+			}
+		})
+	}
+}
+
+func TestWithDedup_Disabled(t *testing.T) {
+	t.Parallel()
+
+	r := NewRingBuffer(0, 64)
+	_, _ = r.Write([]byte("a"))
+	_, _ = r.Write([]byte("a"))
+
+	if got := r.String(); got != "aa" {
+		t.Errorf("String() got = %q, want %q", got, "aa")
+	}
+	if got := r.Repeats(); got != 0 {
+		t.Errorf("Repeats() got = %d, want 0", got)
+	}
+}
+
+// onceFailingWriter fails the first write with err, then accepts the others.
+type onceFailingWriter struct {
+	err error
+}
+
+func (w *onceFailingWriter) Write(p []byte) (int, error) {
+	if err := w.err; err != nil {
+		w.err = nil
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func TestWithDedup_retryAfterFailure(t *testing.T) {
+	t.Parallel()
+
+	errDisk := errors.New("disk full")
+	r := NewRingBuffer(0, 4, WithDedup(), WithSpill(&onceFailingWriter{errDisk}, SpillFail))
+	_, _ = r.Write([]byte("abcd"))
+
+	if n, err := r.Write([]byte("ef")); n != 0 || !errors.Is(err, errDisk) {
+		t.Fatalf("Write() got = %d, %v, want 0, %v", n, err, errDisk)
+	}
+	if n, err := r.Write([]byte("ef")); n != 2 || err != nil {
+		t.Fatalf("Write() retry got = %d, %v, want 2, nil", n, err)
+	}
+
+	if got := r.String(); got != "cdef" {
+		t.Errorf("String() got = %q, want %q", got, "cdef")
+	}
+	if got := r.Repeats(); got != 0 {
+		t.Errorf("Repeats() got = %d, want 0", got)
+	}
+}
@@ -0,0 +1,243 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"encoding"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// interfaces implemented by RingBuffer
+var (
+	_ encoding.BinaryMarshaler   = (*RingBuffer)(nil)
+	_ encoding.BinaryUnmarshaler = (*RingBuffer)(nil)
+)
+
+// StringBase64 returns the buffer content encoded with the standard base64
+// encoding.
+// The two parts of the content are streamed through the encoder, without
+// building an intermediate copy of the whole content.
+func (r *RingBuffer) StringBase64() string {
+	var sb strings.Builder
+	sb.Grow(base64.StdEncoding.EncodedLen(r.Len()))
+
+	first, second := r.Segments()
+
+	// writes to a strings.Builder never fail
+	enc := base64.NewEncoder(base64.StdEncoding, &sb)
+	_, _ = enc.Write(first)
+	_, _ = enc.Write(second)
+	_ = enc.Close()
+
+	return sb.String()
+}
+
+// HexDump returns a hex dump of the buffer content, in the same format of
+// hex.Dump.
+// Like StringBase64, the two parts of the content are streamed through the
+// dumper, so the offsets and the ASCII gutter run across the wrap point.
+func (r *RingBuffer) HexDump() string {
+	var sb strings.Builder
+
+	first, second := r.Segments()
+
+	// writes to a strings.Builder never fail
+	d := hex.Dumper(&sb)
+	_, _ = d.Write(first)
+	_, _ = d.Write(second)
+	_ = d.Close()
+
+	return sb.String()
+}
+
+// WriteToGzip writes the buffer content to w compressed with gzip, leaving
+// the buffer unchanged like CopyTo.
+// The two parts of the content are streamed through the compressor, without
+// building an intermediate copy of either the whole content or its
+// compressed form.
+// The return value is the number of uncompressed bytes written. Any error
+// encountered during the write is also returned.
+func (r *RingBuffer) WriteToGzip(w io.Writer) (int64, error) {
+	zw := gzip.NewWriter(w)
+	n, err := r.CopyTo(zw)
+	if err != nil {
+		return n, err
+	}
+	return n, zw.Close()
+}
+
+// maxInt is the maximum value of an int.
+const maxInt = int(^uint(0) >> 1)
+
+// binaryVersion is the version of the format produced by MarshalBinary.
+const binaryVersion = 1
+
+// flags of the binary format header byte
+const (
+	binaryVersionMask    = 0x0f
+	binaryFlagRing       = 0x10
+	binaryFlagCompressed = 0x20
+)
+
+// binaryCompressThreshold is the size of the underlying buffer from which
+// MarshalBinary compresses it: below it, the saving is not worth the time.
+const binaryCompressThreshold = 4096
+
+// MarshalBinary encodes the buffer state in a compact binary form, stable
+// across versions of this package.
+// The format is:
+//   - a header byte, holding the format version in the lower 4 bits and
+//     flags in the upper 4 bits (0x10: ring mode, 0x20: compressed);
+//   - pos, written and maxSize, each encoded as an unsigned varint, like
+//     encoding/binary.PutUvarint does;
+//   - the underlying buffer up to pos, or the whole of it in ring mode,
+//     compressed with DEFLATE (compress/flate) if the compressed flag is
+//     set.
+//
+// The content is the underlying buffer up to pos, or, in ring mode, the
+// underlying buffer from pos to the end followed by the one up to pos.
+// Out of ring mode, the stale bytes after pos are not encoded.
+// The encoded buffer is compressed when it is at least 4KB and the
+// compression actually makes it smaller.
+// Options are not encoded.
+func (r *RingBuffer) MarshalBinary() ([]byte, error) {
+	header := byte(binaryVersion)
+	if r.ringMode {
+		header |= binaryFlagRing
+	}
+
+	// out of ring mode, the bytes after pos are stale, e.g. left there by
+	// Reset, and so are the drained ones before off: they must not be leaked
+	payload, pos := r.buf, r.pos
+	if !r.ringMode {
+		payload, pos = r.buf[r.off:r.pos], r.pos-r.off
+	}
+	if len(payload) >= binaryCompressThreshold {
+		compressed, err := deflate(payload)
+		if err != nil {
+			return nil, err
+		}
+		if len(compressed) < len(payload) {
+			payload = compressed
+			header |= binaryFlagCompressed
+		}
+	}
+
+	out := make([]byte, 1, 1+3*binary.MaxVarintLen64+len(payload))
+	out[0] = header
+
+	var tmp [binary.MaxVarintLen64]byte
+	for _, v := range []int{pos, r.written, r.maxSize} {
+		n := binary.PutUvarint(tmp[:], uint64(v))
+		out = append(out, tmp[:n]...)
+	}
+
+	return append(out, payload...), nil
+}
+
+// deflate returns p compressed with DEFLATE.
+func deflate(p []byte) ([]byte, error) {
+	var out bytes.Buffer
+	w, err := flate.NewWriter(&out, flate.BestSpeed)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(p); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// inflate returns p decompressed with DEFLATE, or ErrInvalidFormat if it is
+// malformed or longer than limit bytes once decompressed.
+func inflate(p []byte, limit int) ([]byte, error) {
+	var out bytes.Buffer
+	fr := flate.NewReader(bytes.NewReader(p))
+	n, err := out.ReadFrom(io.LimitReader(fr, int64(limit)+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFormat, err)
+	}
+	if n > int64(limit) {
+		return nil, fmt.Errorf("%w: compressed content longer than %d bytes", ErrInvalidFormat, limit)
+	}
+	return out.Bytes(), nil
+}
+
+// UnmarshalBinary decodes a buffer state produced by MarshalBinary, replacing
+// the current content. Options already set on r are kept.
+// It returns ErrUnsupportedVersion for data encoded with a different
+// version of the format, ErrInvalidFormat if data is malformed and
+// ErrFrozen if the buffer is frozen.
+func (r *RingBuffer) UnmarshalBinary(data []byte) error {
+	if err := r.checkWritable(); err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return ErrInvalidFormat
+	}
+
+	header := data[0]
+	if v := header & binaryVersionMask; v != binaryVersion {
+		return fmt.Errorf("%w: %d", ErrUnsupportedVersion, v)
+	}
+	if flags := header &^ binaryVersionMask; flags&^(binaryFlagRing|binaryFlagCompressed) != 0 {
+		return fmt.Errorf("%w: unknown flags %#x", ErrInvalidFormat, flags)
+	}
+	data = data[1:]
+
+	var fields [3]int
+	for i := range fields {
+		v, n := binary.Uvarint(data)
+		if n <= 0 || v > uint64(maxInt) {
+			return ErrInvalidFormat
+		}
+		fields[i] = int(v)
+		data = data[n:]
+	}
+
+	var buf []byte
+	if header&binaryFlagCompressed != 0 {
+		var err error
+		if buf, err = inflate(data, fields[2]); err != nil {
+			return err
+		}
+	} else {
+		buf = make([]byte, len(data))
+		copy(buf, data)
+	}
+
+	decoded := RingBuffer{
+		buf:      buf,
+		pos:      fields[0],
+		written:  fields[1],
+		ringMode: header&binaryFlagRing != 0,
+		maxSize:  fields[2],
+	}
+	if err := decoded.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFormat, err)
+	}
+
+	r.invalidateString()
+	r.buf = decoded.buf
+	r.off = 0
+	r.pos = decoded.pos
+	r.written = decoded.written
+	r.ringMode = decoded.ringMode
+	r.maxSize = decoded.maxSize
+
+	// the discarded bytes are not encoded, so a first line not starting at
+	// offset 0 can't be known to be complete
+	r.midLine = decoded.OldestOffset() > 0
+	r.checkDrainBelow()
+	r.checkState()
+	return nil
+}
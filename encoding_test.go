@@ -0,0 +1,514 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestRingBuffer_StringBase64(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		inputBuffer *RingBuffer
+	}{
+		{
+			name:        "empty",
+			inputBuffer: NewRingBuffer(0, 4),
+		},
+		{
+			name: "no ring",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', 'c', 'd', 0},
+				pos:      4,
+				written:  4,
+				ringMode: false,
+				maxSize:  7,
+			},
+		},
+		{
+			name: "ring mode, start in the middle",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', '1', '2', '3', 'f', 'g'},
+				pos:      5,
+				written:  17,
+				ringMode: true,
+				maxSize:  7,
+			},
+		},
+		{
+			name: "ring mode, segments not multiple of 3",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'e', 'b', 'c', 'd'},
+				pos:      1,
+				written:  5,
+				ringMode: true,
+				maxSize:  4,
+			},
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			want := base64.StdEncoding.EncodeToString(tt.inputBuffer.Bytes())
+
+			if got := tt.inputBuffer.StringBase64(); got != want {
+				t.Errorf("StringBase64() got = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_HexDump(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		inputBuffer *RingBuffer
+	}{
+		{
+			name:        "empty",
+			inputBuffer: NewRingBuffer(0, 4),
+		},
+		{
+			name: "no ring",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', 'c', 'd', 0},
+				pos:      4,
+				written:  4,
+				ringMode: false,
+				maxSize:  7,
+			},
+		},
+		{
+			name: "ring mode, start in the middle",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', '1', '2', '3', 'f', 'g'},
+				pos:      5,
+				written:  17,
+				ringMode: true,
+				maxSize:  7,
+			},
+		},
+		{
+			name: "ring mode, lines across the wrap",
+			inputBuffer: &RingBuffer{
+				buf:      []byte("0123456789abcdefghijklmnopqrstuvwxyz\x00\x01\x02\xff"),
+				pos:      21,
+				written:  100,
+				ringMode: true,
+				maxSize:  40,
+			},
+		},
+		{
+			name: "ring mode, segments not multiple of 3",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'e', 'b', 'c', 'd'},
+				pos:      1,
+				written:  5,
+				ringMode: true,
+				maxSize:  4,
+			},
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			want := hex.Dump(tt.inputBuffer.Bytes())
+
+			if got := tt.inputBuffer.HexDump(); got != want {
+				t.Errorf("HexDump() got = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_WriteToGzip(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		inputBuffer *RingBuffer
+	}{
+		{
+			name:        "empty",
+			inputBuffer: NewRingBuffer(0, 4),
+		},
+		{
+			name: "no ring",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', 'c', 'd', 0},
+				pos:      4,
+				written:  4,
+				ringMode: false,
+				maxSize:  7,
+			},
+		},
+		{
+			name: "ring mode, start in the middle",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', '1', '2', '3', 'f', 'g'},
+				pos:      5,
+				written:  17,
+				ringMode: true,
+				maxSize:  7,
+			},
+		},
+		{
+			name: "ring mode, large",
+			inputBuffer: func() *RingBuffer {
+				r := NewRingBuffer(0, 1<<16)
+				_, _ = r.Write(bytes.Repeat([]byte("0123456789abcdef\n"), 5000))
+				return r
+			}(),
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			want := tt.inputBuffer.Bytes()
+
+			var out bytes.Buffer
+			n, err := tt.inputBuffer.WriteToGzip(&out)
+			if err != nil {
+				t.Fatalf("WriteToGzip() error = %v", err)
+			}
+			if n != int64(len(want)) {
+				t.Errorf("WriteToGzip() got = %d, want %d", n, len(want))
+			}
+
+			zr, err := gzip.NewReader(&out)
+			if err != nil {
+				t.Fatalf("gzip.NewReader() error = %v", err)
+			}
+			var got bytes.Buffer
+			if _, err := got.ReadFrom(zr); err != nil {
+				t.Fatalf("decompression error = %v", err)
+			}
+			if !bytes.Equal(got.Bytes(), want) {
+				t.Errorf("WriteToGzip() decompressed got = %q, want %q", got.Bytes(), want)
+			}
+
+			// the content is not consumed
+			if got := tt.inputBuffer.Bytes(); !bytes.Equal(got, want) {
+				t.Errorf("Bytes() after WriteToGzip() got = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_WriteToGzip_error(t *testing.T) {
+	t.Parallel()
+
+	errTest := errors.New("test error")
+
+	r := NewRingBuffer(0, 4)
+	_, _ = r.Write([]byte("abcdef"))
+
+	if _, err := r.WriteToGzip(&limitedWriter{n: 0, err: errTest}); err != errTest {
+		t.Errorf("WriteToGzip() error = %v, wantErr %v", err, errTest)
+	}
+}
+
+func TestRingBuffer_MarshalBinary(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		inputBuffer *RingBuffer
+		wantBuffer  *RingBuffer
+	}{
+		{
+			name:        "empty",
+			inputBuffer: NewRingBuffer(0, 4),
+			wantBuffer: &RingBuffer{
+				buf:     []byte{},
+				maxSize: 4,
+			},
+		},
+		{
+			name: "no ring",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', 'c', 0},
+				pos:      3,
+				written:  3,
+				ringMode: false,
+				maxSize:  400,
+			},
+			wantBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', 'c'},
+				pos:      3,
+				written:  3,
+				ringMode: false,
+				maxSize:  400,
+			},
+		},
+		{
+			name: "ring mode",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', '1', '2', '3', 'f', 'g'},
+				pos:      5,
+				written:  1700,
+				ringMode: true,
+				maxSize:  7,
+			},
+			wantBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', '1', '2', '3', 'f', 'g'},
+				pos:      5,
+				written:  1700,
+				ringMode: true,
+				maxSize:  7,
+				midLine:  true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			data, err := tt.inputBuffer.MarshalBinary()
+			if err != nil {
+				t.Fatalf("MarshalBinary() error = %v", err)
+			}
+
+			got := &RingBuffer{}
+			if err := got.UnmarshalBinary(data); err != nil {
+				t.Fatalf("UnmarshalBinary() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.wantBuffer) {
+				t.Errorf("UnmarshalBinary() got = %+v want %+v", got, tt.wantBuffer)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_MarshalBinary_staleBytes(t *testing.T) {
+	t.Parallel()
+
+	r := NewRingBuffer(0, 32)
+	_, _ = r.Write([]byte("secret-password"))
+	r.Reset()
+	_, _ = r.Write([]byte("x"))
+
+	got, err := r.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+
+	want := []byte{0x01, 0x01, 0x01, 0x20, 'x'}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MarshalBinary() got = %q, want %q", got, want)
+	}
+}
+
+func TestRingBuffer_MarshalBinary_Format(t *testing.T) {
+	t.Parallel()
+
+	r := &RingBuffer{
+		buf:      []byte{'e', 'b', 'c', 'd'},
+		pos:      1,
+		written:  300,
+		ringMode: true,
+		maxSize:  4,
+	}
+
+	got, err := r.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+
+	want := []byte{0x11, 0x01, 0xac, 0x02, 0x04, 'e', 'b', 'c', 'd'}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MarshalBinary() got = %x, want %x", got, want)
+	}
+}
+
+func TestRingBuffer_MarshalBinary_compression(t *testing.T) {
+	t.Parallel()
+
+	random := make([]byte, 8192)
+	rand.New(rand.NewSource(1)).Read(random)
+
+	tests := []struct {
+		name           string
+		content        []byte
+		maxSize        int
+		wantCompressed bool
+	}{
+		{
+			name:           "small",
+			content:        bytes.Repeat([]byte("a"), 100),
+			maxSize:        1024,
+			wantCompressed: false,
+		},
+		{
+			name:           "large, compressible",
+			content:        bytes.Repeat([]byte("log line\n"), 2000),
+			maxSize:        16384,
+			wantCompressed: true,
+		},
+		{
+			name:           "large, ring mode",
+			content:        bytes.Repeat([]byte("log line\n"), 2000),
+			maxSize:        8000,
+			wantCompressed: true,
+		},
+		{
+			name:           "large, incompressible",
+			content:        random,
+			maxSize:        8192,
+			wantCompressed: false,
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(0, tt.maxSize)
+			_, _ = r.Write(tt.content)
+
+			data, err := r.MarshalBinary()
+			if err != nil {
+				t.Fatalf("MarshalBinary() error = %v", err)
+			}
+
+			if got := data[0]&binaryFlagCompressed != 0; got != tt.wantCompressed {
+				t.Errorf("MarshalBinary() compressed = %v, want %v", got, tt.wantCompressed)
+			}
+			if tt.wantCompressed && len(data) >= len(r.buf) {
+				t.Errorf("MarshalBinary() got %d bytes, want less than %d", len(data), len(r.buf))
+			}
+
+			got := &RingBuffer{}
+			if err := got.UnmarshalBinary(data); err != nil {
+				t.Fatalf("UnmarshalBinary() error = %v", err)
+			}
+
+			// the bytes after pos are not encoded out of ring mode
+			want := *r
+			if !r.ringMode {
+				want.buf = r.buf[:r.pos]
+			}
+			if !reflect.DeepEqual(got, &want) {
+				t.Errorf("UnmarshalBinary() got = %q want %q", got.String(), r.String())
+			}
+		})
+	}
+}
+
+func TestRingBuffer_UnmarshalBinary_compressedFlag(t *testing.T) {
+	t.Parallel()
+
+	content := bytes.Repeat([]byte("abcd"), 2000)
+	r := NewRingBuffer(0, len(content))
+	_, _ = r.Write(content)
+
+	compressed, err := r.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+
+	// the flag, not the payload, drives the decoding: without it the
+	// compressed payload is taken as the raw buffer, which is too short
+	data := append([]byte{}, compressed...)
+	data[0] &^= binaryFlagCompressed
+	if err := NewRingBuffer(0, 4).UnmarshalBinary(data); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("UnmarshalBinary() without flag error = %v, want %v", err, ErrInvalidFormat)
+	}
+
+	// with it a raw payload is not valid DEFLATE data
+	raw := []byte{binaryVersion | binaryFlagCompressed, 0x03, 0x03, 0x04, 'a', 'b', 'c', 'd'}
+	if err := NewRingBuffer(0, 4).UnmarshalBinary(raw); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("UnmarshalBinary() with flag error = %v, want %v", err, ErrInvalidFormat)
+	}
+
+	// the decompressed content can't exceed the maximum size
+	payload, err := deflate(content)
+	if err != nil {
+		t.Fatalf("deflate() error = %v", err)
+	}
+	bomb := append([]byte{binaryVersion | binaryFlagCompressed, 0x04, 0x04, 0x04}, payload...)
+	if err := NewRingBuffer(0, 4).UnmarshalBinary(bomb); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("UnmarshalBinary() beyond the maximum size error = %v, want %v", err, ErrInvalidFormat)
+	}
+
+	unknown := []byte{binaryVersion | 0x40, 0x01, 0x01, 0x04, 'a'}
+	if err := NewRingBuffer(0, 4).UnmarshalBinary(unknown); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("UnmarshalBinary() with unknown flags error = %v, want %v", err, ErrInvalidFormat)
+	}
+}
+
+func TestRingBuffer_UnmarshalBinary(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{
+			name:    "empty",
+			data:    nil,
+			wantErr: ErrInvalidFormat,
+		},
+		{
+			name:    "old version",
+			data:    []byte{0x00, 0x01, 0x01, 0x04, 'a'},
+			wantErr: ErrUnsupportedVersion,
+		},
+		{
+			name:    "future version",
+			data:    []byte{0x02, 0x01, 0x01, 0x04, 'a'},
+			wantErr: ErrUnsupportedVersion,
+		},
+		{
+			name:    "truncated",
+			data:    []byte{0x01, 0x01},
+			wantErr: ErrInvalidFormat,
+		},
+		{
+			name:    "pos out of range",
+			data:    []byte{0x01, 0x05, 0x05, 0x08, 'a'},
+			wantErr: ErrInvalidFormat,
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(0, 4)
+			err := r.UnmarshalBinary(tt.data)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("UnmarshalBinary() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			// state is left untouched on error
+			if !reflect.DeepEqual(r, NewRingBuffer(0, 4)) {
+				t.Errorf("UnmarshalBinary() modified the buffer on error: %+v", r)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package ringbuffer
+
+import "errors"
+
+// Errors returned by the package.
+// Most of them are wrapped with details about the failure, like the sizes
+// involved, so they should be checked with errors.Is.
+var (
+	// ErrTooLarge is returned when the memory for the buffer can't be
+	// allocated.
+	ErrTooLarge = errors.New("ringbuffer: too large")
+
+	// ErrRecordTooLarge is returned by Write, under the OverflowReject
+	// policy, when a single write is larger than the maximum size of the
+	// buffer.
+	ErrRecordTooLarge = errors.New("ringbuffer: record too large")
+
+	// ErrWriteTooLarge is returned by Write when a single write is larger
+	// than the limit set with WithMaxWriteSize.
+	ErrWriteTooLarge = errors.New("ringbuffer: write too large")
+
+	// ErrEvicted is returned when the requested content has already been
+	// overwritten.
+	ErrEvicted = errors.New("ringbuffer: content evicted")
+
+	// ErrOutOfRange is returned when the requested content goes beyond what
+	// has been written so far.
+	ErrOutOfRange = errors.New("ringbuffer: out of range")
+
+	// ErrFrozen is returned by the methods writing into a frozen buffer.
+	ErrFrozen = errors.New("ringbuffer: buffer is frozen")
+
+	// ErrClosed is returned by the methods writing into a closed buffer.
+	ErrClosed = errors.New("ringbuffer: buffer is closed")
+
+	// ErrInvalidState is returned by Validate when the internal fields of
+	// the buffer are inconsistent.
+	ErrInvalidState = errors.New("ringbuffer: invalid state")
+
+	// ErrUnsupportedVersion is returned by UnmarshalBinary when the data has
+	// been produced with an unknown version of the binary format.
+	ErrUnsupportedVersion = errors.New("ringbuffer: unsupported binary format version")
+
+	// ErrInvalidFormat is returned by UnmarshalBinary when the data is
+	// malformed.
+	ErrInvalidFormat = errors.New("ringbuffer: invalid binary format")
+
+	// ErrInvalidJSON is returned by JSONLines.Write when a line is not a
+	// valid JSON value.
+	ErrInvalidJSON = errors.New("ringbuffer: invalid JSON")
+)
@@ -0,0 +1,158 @@
+package ringbuffer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		do      func() error
+		wantErr error
+	}{
+		{
+			name: "too large",
+			do: func() error {
+				_, err := makeSlice(-1)
+				return err
+			},
+			wantErr: ErrTooLarge,
+		},
+		{
+			name: "record too large",
+			do: func() error {
+				r := NewRingBuffer(0, 4, WithOverflowPolicy(OverflowReject))
+				_, err := r.Write([]byte("abcdef"))
+				return err
+			},
+			wantErr: ErrRecordTooLarge,
+		},
+		{
+			name: "write too large",
+			do: func() error {
+				r := NewRingBuffer(0, 8, WithMaxWriteSize(2))
+				_, err := r.Write([]byte("abc"))
+				return err
+			},
+			wantErr: ErrWriteTooLarge,
+		},
+		{
+			name: "evicted",
+			do: func() error {
+				r := NewRingBuffer(0, 4)
+				_, _ = r.Write([]byte("abcdef"))
+				_, err := r.OffsetBytes(0, 1)
+				return err
+			},
+			wantErr: ErrEvicted,
+		},
+		{
+			name: "out of range",
+			do: func() error {
+				r := NewRingBuffer(0, 4)
+				_, _ = r.Write([]byte("ab"))
+				_, err := r.OffsetBytes(1, 2)
+				return err
+			},
+			wantErr: ErrOutOfRange,
+		},
+		{
+			name: "frozen",
+			do: func() error {
+				r := NewRingBuffer(0, 4)
+				r.Freeze()
+				_, err := r.Write([]byte("ab"))
+				return err
+			},
+			wantErr: ErrFrozen,
+		},
+		{
+			name: "closed write",
+			do: func() error {
+				r := NewRingBuffer(0, 4)
+				_ = r.Close()
+				_, err := r.Write([]byte("ab"))
+				return err
+			},
+			wantErr: ErrClosed,
+		},
+		{
+			name: "closed read from",
+			do: func() error {
+				r := NewRingBuffer(0, 4)
+				_ = r.Close()
+				_, err := r.ReadFrom(strings.NewReader("ab"))
+				return err
+			},
+			wantErr: ErrClosed,
+		},
+		{
+			name: "closed and frozen",
+			do: func() error {
+				r := NewRingBuffer(0, 4)
+				r.Freeze()
+				_ = r.Close()
+				return r.SetMaxSize(8)
+			},
+			wantErr: ErrClosed,
+		},
+		{
+			name: "invalid state",
+			do: func() error {
+				r := &RingBuffer{buf: make([]byte, 4), pos: 5, maxSize: 4}
+				return r.Validate()
+			},
+			wantErr: ErrInvalidState,
+		},
+		{
+			name: "unsupported version",
+			do: func() error {
+				return NewRingBuffer(0, 4).UnmarshalBinary([]byte{0x0f})
+			},
+			wantErr: ErrUnsupportedVersion,
+		},
+		{
+			name: "invalid format",
+			do: func() error {
+				return NewRingBuffer(0, 4).UnmarshalBinary([]byte{binaryVersion, 9, 0, 4})
+			},
+			wantErr: ErrInvalidFormat,
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tt.do()
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+			if !strings.HasPrefix(err.Error(), "ringbuffer: ") {
+				t.Errorf("error = %q, want prefix %q", err, "ringbuffer: ")
+			}
+		})
+	}
+}
+
+func TestErrors_distinct(t *testing.T) {
+	t.Parallel()
+
+	all := []error{
+		ErrTooLarge, ErrRecordTooLarge, ErrWriteTooLarge, ErrEvicted, ErrOutOfRange, ErrFrozen,
+		ErrClosed, ErrInvalidState, ErrUnsupportedVersion, ErrInvalidFormat,
+		ErrInvalidJSON,
+	}
+	for i, a := range all {
+		for j, b := range all {
+			if (i == j) != errors.Is(a, b) {
+				t.Errorf("errors.Is(%v, %v) got = %v, want %v", a, b, !(i == j), i == j)
+			}
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package ringbuffer
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// WriteFrame appends p to the buffer as a single frame, to be read back
+// whole by a FrameReader: the frame is stored as the length of p, encoded
+// as an unsigned varint, followed by p.
+// Like Write, it is safe to call it from many goroutines, and every frame is
+// stored contiguously, never interleaved with the ones of the other writers.
+// When there is no room left, the oldest frames are dropped whole, so the
+// buffer never holds a truncated frame.
+// It returns ErrRecordTooLarge if the frame doesn't fit in the maximum size.
+// The buffer must be written with WriteFrame only, and without the options
+// changing the content stored by Write, like WithDedup or
+// WithMaxLineLength, otherwise the frames can't be read back.
+func (m *MPSCRingBuffer) WriteFrame(p []byte) (int, error) {
+	var header [binary.MaxVarintLen64]byte
+	h := binary.PutUvarint(header[:], uint64(len(p)))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// a closed buffer has a maximum size of 0, so it must be checked first
+	if err := m.rb.checkWritable(); err != nil {
+		return 0, err
+	}
+	if size := h + len(p); size > m.rb.maxSize {
+		return 0, fmt.Errorf("%w: frame of %d bytes, maximum size %d", ErrRecordTooLarge, size, m.rb.maxSize)
+	}
+
+	// drop the oldest frames until the new one fits
+	var drop int
+	for m.rb.Len()-drop+h+len(p) > m.rb.maxSize {
+		size, err := m.rb.frameSize(drop)
+		if err != nil {
+			return 0, err
+		}
+		drop += size
+	}
+	m.rb.Drain(drop)
+
+	if _, err := m.rb.WriteMulti(header[:h], p); err != nil {
+		return 0, err
+	}
+	m.bump()
+	return len(p), nil
+}
+
+// FrameReader reads back the frames written into a MPSCRingBuffer with
+// WriteFrame, in the order they have been written.
+type FrameReader struct {
+	m *MPSCRingBuffer
+}
+
+// NewFrameReader creates a new FrameReader consuming the frames of m.
+func NewFrameReader(m *MPSCRingBuffer) *FrameReader {
+	return &FrameReader{m: m}
+}
+
+// ReadFrame returns a copy of the oldest frame, consuming it.
+// It returns io.EOF if there is no frame to read, and an error wrapping
+// ErrInvalidFormat if the content is not made of frames.
+func (fr *FrameReader) ReadFrame() ([]byte, error) {
+	m := fr.m
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.rb.Len() == 0 {
+		return nil, io.EOF
+	}
+
+	size, err := m.rb.frameSize(0)
+	if err != nil {
+		return nil, err
+	}
+
+	frame := make([]byte, size)
+	m.rb.readAt(frame, 0)
+	m.rb.Drain(size)
+	m.bump()
+
+	_, h := binary.Uvarint(frame)
+	return frame[h:], nil
+}
+
+// frameSize returns the size, including the header, of the frame starting
+// at the logical index i.
+func (r *RingBuffer) frameSize(i int) (int, error) {
+	var header [binary.MaxVarintLen64]byte
+	n := r.readAt(header[:], i)
+
+	v, h := binary.Uvarint(header[:n])
+	if h <= 0 || v > uint64(r.Len()-i-h) {
+		return 0, fmt.Errorf("%w: no valid frame at %d", ErrInvalidFormat, i)
+	}
+	return h + int(v), nil
+}
@@ -0,0 +1,137 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestMPSCRingBuffer_WriteFrame(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		maxSize  int
+		wantLoss bool
+	}{
+		{name: "no drops", maxSize: 1 << 20, wantLoss: false},
+		{name: "oldest frames dropped", maxSize: 256, wantLoss: true},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			const (
+				producers = 16
+				frames    = 300
+			)
+
+			m := NewMPSCRingBuffer(0, tt.maxSize)
+			fr := NewFrameReader(m)
+
+			var wg sync.WaitGroup
+			for p := 0; p < producers; p++ {
+				wg.Add(1)
+				go func(p int) {
+					defer wg.Done()
+					for i := 0; i < frames; i++ {
+						// variable size frames: "pp:iiii:" followed by i%50 'x'
+						frame := fmt.Sprintf("%02d:%04d:%s", p, i, strings.Repeat("x", i%50))
+						if _, err := m.WriteFrame([]byte(frame)); err != nil {
+							t.Errorf("WriteFrame() error = %v", err)
+							return
+						}
+					}
+				}(p)
+			}
+
+			done := make(chan struct{})
+			go func() {
+				wg.Wait()
+				close(done)
+			}()
+
+			var got [][]byte
+			for stop := false; !stop; {
+				select {
+				case <-done:
+					stop = true
+				default:
+				}
+
+				for {
+					frame, err := fr.ReadFrame()
+					if err == io.EOF {
+						break
+					}
+					if err != nil {
+						t.Fatalf("ReadFrame() error = %v", err)
+					}
+					got = append(got, frame)
+				}
+			}
+
+			// every frame is intact and in order for its producer
+			last := make(map[int]int)
+			for _, frame := range got {
+				var p, i int
+				if _, err := fmt.Sscanf(string(frame[:8]), "%02d:%04d:", &p, &i); err != nil {
+					t.Fatalf("corrupted frame %q: %v", frame, err)
+				}
+				if want := strings.Repeat("x", i%50); string(frame[8:]) != want {
+					t.Fatalf("corrupted frame %q", frame)
+				}
+				if prev, ok := last[p]; ok && i <= prev {
+					t.Fatalf("frame %d of producer %d read after frame %d", i, p, prev)
+				}
+				last[p] = i
+			}
+
+			if lost := len(got) < producers*frames; lost != tt.wantLoss {
+				t.Errorf("read %d frames of %d, want loss %v", len(got), producers*frames, tt.wantLoss)
+			}
+		})
+	}
+}
+
+func TestMPSCRingBuffer_WriteFrame_errors(t *testing.T) {
+	t.Parallel()
+
+	m := NewMPSCRingBuffer(0, 8)
+	fr := NewFrameReader(m)
+
+	if _, err := m.WriteFrame([]byte("12345678")); !errors.Is(err, ErrRecordTooLarge) {
+		t.Errorf("WriteFrame() error = %v, want %v", err, ErrRecordTooLarge)
+	}
+
+	_, _ = m.WriteFrame([]byte("abc"))
+	_, _ = m.WriteFrame([]byte("de"))
+	_, _ = m.WriteFrame([]byte("fgh"))
+
+	// "abc" has been dropped whole to make room for "fgh"
+	for _, want := range []string{"de", "fgh"} {
+		if got, err := fr.ReadFrame(); err != nil || !bytes.Equal(got, []byte(want)) {
+			t.Errorf("ReadFrame() got = %q, %v, want %q, nil", got, err, want)
+		}
+	}
+	if _, err := fr.ReadFrame(); err != io.EOF {
+		t.Errorf("ReadFrame() error = %v, want %v", err, io.EOF)
+	}
+
+	// content not written with WriteFrame
+	_, _ = m.Write([]byte{0x7f, 'a'})
+	if _, err := fr.ReadFrame(); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("ReadFrame() error = %v, want %v", err, ErrInvalidFormat)
+	}
+
+	_ = m.Close()
+	if _, err := m.WriteFrame([]byte("abc")); err != ErrClosed {
+		t.Errorf("WriteFrame() error = %v, want %v", err, ErrClosed)
+	}
+}
@@ -0,0 +1,20 @@
+package ringbuffer
+
+// Freeze makes the buffer read-only: Write, and every method writing
+// through it, returns ErrFrozen, while Reset, ResetZero and ClearContent do
+// nothing. Methods reading the content keep working, Read included.
+// It is a cheap way to hand the buffer to another component which must not
+// change it, without cloning it.
+func (r *RingBuffer) Freeze() {
+	r.frozen = true
+}
+
+// Unfreeze makes the buffer writable again after a Freeze.
+func (r *RingBuffer) Unfreeze() {
+	r.frozen = false
+}
+
+// Frozen reports whether the buffer has been made read-only by Freeze.
+func (r *RingBuffer) Frozen() bool {
+	return r.frozen
+}
@@ -0,0 +1,139 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRingBuffer_Freeze(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		mutate  func(r *RingBuffer) error
+		wantErr error
+	}{
+		{
+			name: "write",
+			mutate: func(r *RingBuffer) error {
+				_, err := r.Write([]byte("x"))
+				return err
+			},
+			wantErr: ErrFrozen,
+		},
+		{
+			name: "write fmt",
+			mutate: func(r *RingBuffer) error {
+				_, err := r.WriteFmt("%d", 1)
+				return err
+			},
+			wantErr: ErrFrozen,
+		},
+		{
+			name: "read from",
+			mutate: func(r *RingBuffer) error {
+				_, err := r.ReadFrom(bytes.NewReader([]byte("x")))
+				return err
+			},
+			wantErr: ErrFrozen,
+		},
+		{
+			name: "read from at",
+			mutate: func(r *RingBuffer) error {
+				_, err := r.ReadFromAt(bytes.NewReader([]byte("x")), 0, 1)
+				return err
+			},
+			wantErr: ErrFrozen,
+		},
+		{
+			name: "unmarshal",
+			mutate: func(r *RingBuffer) error {
+				return r.UnmarshalBinary([]byte{0x01, 0x01, 0x01, 0x04, 'x'})
+			},
+			wantErr: ErrFrozen,
+		},
+		{
+			name: "reset",
+			mutate: func(r *RingBuffer) error {
+				r.Reset()
+				return nil
+			},
+		},
+		{
+			name: "reset zero",
+			mutate: func(r *RingBuffer) error {
+				r.ResetZero()
+				return nil
+			},
+		},
+		{
+			name: "clear content",
+			mutate: func(r *RingBuffer) error {
+				r.ClearContent()
+				return nil
+			},
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(0, 4)
+			_, _ = r.Write([]byte("abcde"))
+			r.Freeze()
+
+			if !r.Frozen() {
+				t.Errorf("Frozen() got = false, want true")
+			}
+
+			if err := tt.mutate(r); err != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got := r.String(); got != "bcde" {
+				t.Errorf("String() got = %q, want %q", got, "bcde")
+			}
+			if got := r.Written(); got != 5 {
+				t.Errorf("Written() got = %d, want %d", got, 5)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_Unfreeze(t *testing.T) {
+	t.Parallel()
+
+	r := NewRingBuffer(0, 4)
+	r.Freeze()
+	r.Unfreeze()
+
+	if r.Frozen() {
+		t.Errorf("Frozen() got = true, want false")
+	}
+
+	if _, err := r.Write([]byte("abc")); err != nil {
+		t.Errorf("Write() error = %v", err)
+	}
+	if got := r.String(); got != "abc" {
+		t.Errorf("String() got = %q, want %q", got, "abc")
+	}
+}
+
+func TestRingBuffer_Freeze_Read(t *testing.T) {
+	t.Parallel()
+
+	r := NewRingBuffer(0, 4)
+	_, _ = r.Write([]byte("abc"))
+	r.Freeze()
+
+	if got := r.Bytes(); string(got) != "abc" {
+		t.Errorf("Bytes() got = %q, want %q", got, "abc")
+	}
+
+	p := make([]byte, 2)
+	if n, err := r.Read(p); err != nil || string(p[:n]) != "ab" {
+		t.Errorf("Read() got = %q, %v, want %q, nil", p[:n], err, "ab")
+	}
+}
@@ -0,0 +1,88 @@
+package ringbuffer
+
+// Group enforces a limit on the total memory allocated by a set of
+// RingBuffers, e.g. one per tenant of a multi-tenant logger.
+// When the sum of the registered buffers Cap() exceeds the limit, the
+// biggest buffers are shrunk with SetMaxSize, dropping their oldest content,
+// until the total fits.
+// A Group is not safe for concurrent use, and neither are the buffers it
+// shrinks.
+type Group struct {
+	limit   int
+	buffers []*RingBuffer
+}
+
+// NewGroup creates a new Group limiting the total memory of its buffers to
+// limit bytes.
+func NewGroup(limit int) *Group {
+	return &Group{
+		limit: limit,
+	}
+}
+
+// Register adds r to the group, then enforces the limit like Enforce.
+func (g *Group) Register(r *RingBuffer) error {
+	g.buffers = append(g.buffers, r)
+	return g.Enforce()
+}
+
+// Cap returns the total memory allocated by the registered buffers.
+func (g *Group) Cap() int {
+	total := 0
+	for _, r := range g.buffers {
+		total += r.Cap()
+	}
+	return total
+}
+
+// Enforce shrinks the biggest registered buffers until the total memory
+// allocated fits the limit of the group.
+// All the buffers bigger than a common level are shrunk to that level,
+// which is the highest one keeping the total within the limit.
+// Since their maximum size is lowered too, they can't grow again, but
+// buffers still growing can make the total exceed the limit again, so
+// Enforce should be called periodically.
+func (g *Group) Enforce() error {
+	if g.Cap() <= g.limit {
+		return nil
+	}
+
+	// binary search of the highest level keeping the total within the limit
+	lo, hi := 0, 0
+	for _, r := range g.buffers {
+		if r.Cap() > hi {
+			hi = r.Cap()
+		}
+	}
+	for lo < hi {
+		level := lo + (hi-lo+1)/2
+		if g.capAtLevel(level) <= g.limit {
+			lo = level
+		} else {
+			hi = level - 1
+		}
+	}
+
+	for _, r := range g.buffers {
+		if r.Cap() > lo {
+			if err := r.SetMaxSize(lo); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// capAtLevel returns the total memory the registered buffers would allocate
+// if all the ones bigger than level were shrunk to it.
+func (g *Group) capAtLevel(level int) int {
+	total := 0
+	for _, r := range g.buffers {
+		if c := r.Cap(); c < level {
+			total += c
+		} else {
+			total += level
+		}
+	}
+	return total
+}
@@ -0,0 +1,120 @@
+package ringbuffer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGroup(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		limit    int
+		sizes    []int
+		wantCaps []int
+	}{
+		{
+			name:     "within the limit",
+			limit:    100,
+			sizes:    []int{10, 20, 30},
+			wantCaps: []int{10, 20, 30},
+		},
+		{
+			name:     "shrink the biggest",
+			limit:    50,
+			sizes:    []int{10, 40, 10},
+			wantCaps: []int{10, 30, 10},
+		},
+		{
+			name:     "shrink many to a common level",
+			limit:    60,
+			sizes:    []int{40, 10, 30},
+			wantCaps: []int{25, 10, 25},
+		},
+		{
+			name:     "level not exact",
+			limit:    25,
+			sizes:    []int{20, 20},
+			wantCaps: []int{12, 12},
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			g := NewGroup(tt.limit)
+
+			buffers := make([]*RingBuffer, len(tt.sizes))
+			for i, size := range tt.sizes {
+				buffers[i] = NewRingBuffer(size, size)
+				_, _ = buffers[i].Write([]byte(strings.Repeat("x", size)))
+				if err := g.Register(buffers[i]); err != nil {
+					t.Fatalf("Register() error = %v", err)
+				}
+			}
+
+			if got := g.Cap(); got > tt.limit {
+				t.Errorf("Cap() got = %d, want <= %d", got, tt.limit)
+			}
+
+			for i, r := range buffers {
+				if got := r.Cap(); got != tt.wantCaps[i] {
+					t.Errorf("buffer %d Cap() got = %d, want %d", i, got, tt.wantCaps[i])
+				}
+				if got := r.Len(); got != tt.wantCaps[i] {
+					t.Errorf("buffer %d Len() got = %d, want %d", i, got, tt.wantCaps[i])
+				}
+			}
+		})
+	}
+}
+
+func TestGroup_Enforce(t *testing.T) {
+	t.Parallel()
+
+	g := NewGroup(30)
+
+	a := NewRingBuffer(0, 100)
+	b := NewRingBuffer(0, 100)
+	_ = g.Register(a)
+	_ = g.Register(b)
+
+	// buffers growing after registration exceed the limit until Enforce
+	_, _ = a.Write([]byte(strings.Repeat("a", 32)))
+	_, _ = b.Write([]byte("0123456789abcdef"))
+
+	if err := g.Enforce(); err != nil {
+		t.Fatalf("Enforce() error = %v", err)
+	}
+
+	if got := g.Cap(); got > 30 {
+		t.Errorf("Cap() got = %d, want <= 30", got)
+	}
+	if got := b.String(); got != "123456789abcdef" {
+		t.Errorf("String() got = %q, want the newest content", got)
+	}
+}
+
+func TestGroup_Register_adoptedBuffer(t *testing.T) {
+	t.Parallel()
+
+	g := NewGroup(10)
+
+	// the adopted slice is larger than the maximum size, so it is replaced
+	// and its memory is not retained
+	adopted := make([]byte, 4, 100)
+	r := NewRingBufferUsing(adopted, 8)
+	if err := g.Register(r); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	if got := g.Cap(); got != 4 {
+		t.Errorf("Cap() got = %d, want 4", got)
+	}
+	if &r.Buffer()[0] == &adopted[0] {
+		t.Errorf("NewRingBufferUsing() kept the backing array of %d bytes", cap(adopted))
+	}
+}
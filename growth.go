@@ -0,0 +1,70 @@
+package ringbuffer
+
+// GrowthStrategy returns the size the underlying buffer grows to from the
+// current size, which is always positive. Grow applies it repeatedly until
+// the new size is enough, then limits it to the maximum size.
+// A result not greater than the current size makes the buffer grow straight
+// to the size needed.
+type GrowthStrategy func(current int) int
+
+// WithGrowth sets the strategy used to grow the underlying buffer, instead
+// of doubling its size, e.g. to avoid huge allocations on buffers with a
+// large maximum size.
+func WithGrowth(g GrowthStrategy) Option {
+	return func(r *RingBuffer) {
+		r.growth = g
+	}
+}
+
+// GeometricGrowth multiplies the size by factor at every step.
+// A factor lower than 2 is considered 2, the default growth.
+func GeometricGrowth(factor int) GrowthStrategy {
+	if factor < 2 {
+		factor = 2
+	}
+	return func(current int) int {
+		return current * factor
+	}
+}
+
+// LinearGrowth adds increment bytes to the size at every step.
+// An increment lower than 1 is considered 1.
+func LinearGrowth(increment int) GrowthStrategy {
+	if increment < 1 {
+		increment = 1
+	}
+	return func(current int) int {
+		return current + increment
+	}
+}
+
+// HybridGrowth multiplies the size by factor, like GeometricGrowth, until it
+// reaches threshold, and then adds increment bytes at every step, like
+// LinearGrowth, so that the over-allocation is never more than increment
+// bytes, e.g. HybridGrowth(2, 512<<20, 64<<20) doubles up to 512MB and
+// then grows by 64MB.
+// A geometric step never goes beyond threshold.
+func HybridGrowth(factor, threshold, increment int) GrowthStrategy {
+	geometric, linear := GeometricGrowth(factor), LinearGrowth(increment)
+	return func(current int) int {
+		if current >= threshold {
+			return linear(current)
+		}
+		if next := geometric(current); next < threshold {
+			return next
+		}
+		return threshold
+	}
+}
+
+// nextSize returns the size following n in the growth of the underlying
+// buffer, or the needed size if the strategy doesn't grow beyond n.
+func (r *RingBuffer) nextSize(n, needed int) int {
+	if r.growth == nil {
+		return n * expansionFactor
+	}
+	if next := r.growth(n); next > n {
+		return next
+	}
+	return needed
+}
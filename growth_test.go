@@ -0,0 +1,86 @@
+package ringbuffer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWithGrowth(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		growth GrowthStrategy
+		want   []int
+	}{
+		{
+			name:   "default",
+			growth: nil,
+			want:   []int{1, 2, 4, 8, 16, 32, 64, 100},
+		},
+		{
+			name:   "geometric",
+			growth: GeometricGrowth(3),
+			want:   []int{1, 3, 9, 27, 81, 100},
+		},
+		{
+			name:   "geometric, factor too low",
+			growth: GeometricGrowth(1),
+			want:   []int{1, 2, 4, 8, 16, 32, 64, 100},
+		},
+		{
+			name:   "linear",
+			growth: LinearGrowth(16),
+			want:   []int{1, 17, 33, 49, 65, 81, 97, 100},
+		},
+		{
+			name:   "hybrid",
+			growth: HybridGrowth(2, 12, 30),
+			want:   []int{1, 2, 4, 8, 12, 42, 72, 100},
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(0, 100, WithGrowth(tt.growth))
+
+			// write one byte at a time, collecting every new capacity
+			var got []int
+			for i := 0; i < 120; i++ {
+				_ = r.WriteByte('a')
+				if len(got) == 0 || got[len(got)-1] != r.Cap() {
+					got = append(got, r.Cap())
+				}
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Cap() progression got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithGrowth_notGrowing(t *testing.T) {
+	t.Parallel()
+
+	r := NewRingBuffer(4, 100, WithGrowth(func(current int) int { return current }))
+	if err := r.Grow(50); err != nil {
+		t.Fatalf("Grow() error = %v", err)
+	}
+	if got := r.Cap(); got != 50 {
+		t.Errorf("Cap() got = %d, want %d", got, 50)
+	}
+}
+
+func TestWithGrowth_ResetFull(t *testing.T) {
+	t.Parallel()
+
+	r := NewRingBuffer(0, 100, WithGrowth(LinearGrowth(10)))
+	r.ResetFull()
+	if r.growth != nil {
+		t.Errorf("ResetFull() kept the growth strategy")
+	}
+}
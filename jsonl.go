@@ -0,0 +1,108 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// JSONLines is a sink of JSON lines retaining the last n JSON values
+// written, one per line.
+// Unlike a RingBuffer, it evicts whole values rather than bytes, so its
+// content is always made of complete and valid JSON values.
+// A value split across many writes is stored once its line is completed.
+// A JSONLines is not safe for concurrent use.
+type JSONLines struct {
+	objects [][]byte
+	start   int
+	count   int
+	partial []byte
+}
+
+// interfaces implemented by JSONLines
+var _ io.Writer = (*JSONLines)(nil)
+
+// NewJSONLines creates a new JSONLines retaining the last n values.
+// A negative n is considered 0.
+func NewJSONLines(n int) *JSONLines {
+	if n < 0 {
+		n = 0
+	}
+	return &JSONLines{
+		objects: make([][]byte, n),
+	}
+}
+
+// Write stores every JSON value completed by a newline in p, evicting the
+// oldest ones beyond the retention count. Blank lines are ignored.
+// A line that is not a valid JSON value is discarded, and an error wrapping
+// ErrInvalidJSON is returned after storing the other lines of p.
+// It always returns len(p), since all the bytes are consumed.
+func (j *JSONLines) Write(p []byte) (int, error) {
+	var invalid int
+
+	for data := p; ; {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			j.partial = append(j.partial, data...)
+			break
+		}
+
+		line := data[:i]
+		if len(j.partial) > 0 {
+			j.partial = append(j.partial, line...)
+			line = j.partial
+		}
+		if !j.store(line) {
+			invalid++
+		}
+		j.partial = j.partial[:0]
+		data = data[i+1:]
+	}
+
+	if invalid > 0 {
+		return len(p), fmt.Errorf("%w: %d lines discarded", ErrInvalidJSON, invalid)
+	}
+	return len(p), nil
+}
+
+// store adds line as the newest value, reusing the memory of the evicted
+// one, and reports whether it is valid.
+func (j *JSONLines) store(line []byte) bool {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return true
+	}
+	if !json.Valid(line) {
+		return false
+	}
+	if len(j.objects) == 0 {
+		return true
+	}
+
+	i := (j.start + j.count) % len(j.objects)
+	j.objects[i] = append(j.objects[i][:0], line...)
+	if j.count < len(j.objects) {
+		j.count++
+	} else {
+		j.start = (j.start + 1) % len(j.objects)
+	}
+	return true
+}
+
+// Len returns the number of values retained.
+func (j *JSONLines) Len() int {
+	return j.count
+}
+
+// Objects returns a copy of the retained values, from the oldest to the
+// newest, without the trailing newline.
+func (j *JSONLines) Objects() [][]byte {
+	out := make([][]byte, j.count)
+	for k := range out {
+		obj := j.objects[(j.start+k)%len(j.objects)]
+		out[k] = append([]byte(nil), obj...)
+	}
+	return out
+}
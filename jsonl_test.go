@@ -0,0 +1,95 @@
+package ringbuffer
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestJSONLines(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		n       int
+		writes  []string
+		want    []string
+		wantErr error
+	}{
+		{
+			name:   "within the retention count",
+			n:      3,
+			writes: []string{"{\"a\":1}\n", "{\"b\":2}\n"},
+			want:   []string{`{"a":1}`, `{"b":2}`},
+		},
+		{
+			name: "past the retention count",
+			n:    2,
+			writes: []string{
+				"{\"a\":1}\n{\"b\":2}\n",
+				"{\"c\":3}\n",
+				"{\"d\":{\"nested\":[1,2,3]}}\n",
+			},
+			want: []string{`{"c":3}`, `{"d":{"nested":[1,2,3]}}`},
+		},
+		{
+			name:   "value split across writes",
+			n:      2,
+			writes: []string{"{\"a\":", "\"long", " value\"}", "\n{\"b\":2}"},
+			want:   []string{`{"a":"long value"}`},
+		},
+		{
+			name:   "blank lines and spaces",
+			n:      3,
+			writes: []string{"\n  {\"a\":1}  \r\n\n[1,2]\n"},
+			want:   []string{`{"a":1}`, `[1,2]`},
+		},
+		{
+			name:    "invalid lines discarded",
+			n:       3,
+			writes:  []string{"{\"a\":1}\n{broken\n\"ok\"\n"},
+			want:    []string{`{"a":1}`, `"ok"`},
+			wantErr: ErrInvalidJSON,
+		},
+		{
+			name:   "zero retention",
+			n:      0,
+			writes: []string{"{\"a\":1}\n"},
+			want:   []string{},
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			j := NewJSONLines(tt.n)
+
+			var err error
+			for _, w := range tt.writes {
+				n, wErr := j.Write([]byte(w))
+				if n != len(w) {
+					t.Errorf("Write() got = %d, want %d", n, len(w))
+				}
+				if wErr != nil {
+					err = wErr
+				}
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Write() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			got := make([]string, 0)
+			for _, obj := range j.Objects() {
+				got = append(got, string(obj))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Objects() got = %q, want %q", got, tt.want)
+			}
+			if j.Len() != len(tt.want) {
+				t.Errorf("Len() got = %d, want %d", j.Len(), len(tt.want))
+			}
+		})
+	}
+}
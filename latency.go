@@ -0,0 +1,80 @@
+package ringbuffer
+
+import (
+	"math/bits"
+	"time"
+)
+
+// latencyBuckets is the number of buckets of the latency histogram: the
+// bucket i counts the durations d with bits.Len64(d) == i, so the last one
+// is enough for any time.Duration.
+const latencyBuckets = 64
+
+// latencyHistogram counts durations in buckets of exponentially growing
+// width, a power of 2 of nanoseconds each.
+type latencyHistogram struct {
+	counts [latencyBuckets]uint64
+	total  uint64
+}
+
+// Latencies is a summary of the durations of the writes.
+// The percentiles are upper bounds, with a resolution of a power of 2 of
+// nanoseconds.
+type Latencies struct {
+	Count uint64
+	P50   time.Duration
+	P95   time.Duration
+	P99   time.Duration
+}
+
+// WithLatencyTracking enables the measurement of the duration of every
+// Write, summarized by Latencies, e.g. to detect spikes caused by the
+// buffer growing.
+// It is disabled by default, to avoid reading the clock twice on every
+// Write.
+func WithLatencyTracking() Option {
+	return func(r *RingBuffer) {
+		r.latency = &latencyHistogram{}
+	}
+}
+
+// Latencies returns the percentiles of the durations of the writes measured
+// so far. It is all zeros if the tracking has not been enabled with
+// WithLatencyTracking.
+func (r *RingBuffer) Latencies() Latencies {
+	if r.latency == nil {
+		return Latencies{}
+	}
+	return Latencies{
+		Count: r.latency.total,
+		P50:   r.latency.percentile(0.50),
+		P95:   r.latency.percentile(0.95),
+		P99:   r.latency.percentile(0.99),
+	}
+}
+
+// record adds the duration d to the histogram.
+func (h *latencyHistogram) record(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	i := bits.Len64(uint64(d))
+	if i >= latencyBuckets {
+		i = latencyBuckets - 1
+	}
+	h.counts[i]++
+	h.total++
+}
+
+// percentile returns the upper bound of the bucket holding the q-th
+// quantile of the recorded durations, or 0 if none has been recorded.
+func (h *latencyHistogram) percentile(q float64) time.Duration {
+	i := quantileBucket(h.counts[:], q)
+	switch {
+	case i < 0:
+		return 0
+	case i == latencyBuckets-1:
+		return time.Duration(1<<63 - 1)
+	}
+	return time.Duration(1) << uint(i)
+}
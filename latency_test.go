@@ -0,0 +1,85 @@
+package ringbuffer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLatencyHistogram(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		durations map[time.Duration]int
+		want      Latencies
+	}{
+		{
+			name: "empty",
+			want: Latencies{},
+		},
+		{
+			name:      "single value",
+			durations: map[time.Duration]int{100: 10},
+			want:      Latencies{Count: 10, P50: 128, P95: 128, P99: 128},
+		},
+		{
+			name: "tail spikes",
+			durations: map[time.Duration]int{
+				100:                  90,
+				3 * time.Microsecond: 8,
+				time.Millisecond:     2,
+			},
+			want: Latencies{Count: 100, P50: 128, P95: 4096, P99: 1 << 20},
+		},
+		{
+			name:      "zero and negative durations",
+			durations: map[time.Duration]int{0: 1, -5: 1},
+			want:      Latencies{Count: 2, P50: 1, P95: 1, P99: 1},
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(0, 16, WithLatencyTracking())
+			for d, n := range tt.durations {
+				for i := 0; i < n; i++ {
+					r.latency.record(d)
+				}
+			}
+
+			if got := r.Latencies(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Latencies() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_Latencies(t *testing.T) {
+	t.Parallel()
+
+	r := NewRingBuffer(0, 1<<16, WithLatencyTracking())
+	p := make([]byte, 100)
+	for i := 0; i < 1000; i++ {
+		_, _ = r.Write(p)
+	}
+	// in place, in ring mode
+	for i := 0; i < 10; i++ {
+		_, _ = r.WriteWith(len(p), func(dst []byte) int { return copy(dst, p) })
+	}
+
+	got := r.Latencies()
+	if got.Count != 1010 {
+		t.Errorf("Latencies() Count got = %d, want 1010", got.Count)
+	}
+	if got.P50 <= 0 || got.P50 > got.P95 || got.P95 > got.P99 {
+		t.Errorf("Latencies() got = %+v, want populated and ordered percentiles", got)
+	}
+
+	if got := NewRingBuffer(0, 16).Latencies(); got != (Latencies{}) {
+		t.Errorf("Latencies() without tracking got = %+v, want zero", got)
+	}
+}
@@ -0,0 +1,214 @@
+package ringbuffer
+
+import "bytes"
+
+// OnLine registers fn to be called for every complete line written into the
+// buffer, in order, with the line content without the trailing '\n'.
+// A line split across many writes is delivered once it is completed, and a
+// single write can complete many lines. Writes skipped by WithDedup are not
+// considered.
+// The slice passed to fn is valid only during the call.
+// A nil fn removes the callback.
+func (r *RingBuffer) OnLine(fn func(line []byte)) {
+	r.onLine = fn
+	r.partialLine = r.partialLine[:0]
+}
+
+// WithMaxLineLength truncates every line longer than n bytes before storing
+// it, so that a single huge line can't take the whole buffer: only the first
+// n bytes of the line are kept, followed by marker (e.g. "..."), and the
+// rest is dropped up to the next '\n'.
+// Lines are tracked across writes, and Write still reports the bytes
+// dropped as written. OnLine receives the truncated lines.
+// A n <= 0 disables the truncation.
+func WithMaxLineLength(n int, marker string) Option {
+	return func(r *RingBuffer) {
+		if n <= 0 {
+			n = 0
+		}
+		r.maxLineLength = n
+		r.lineMarker = []byte(marker)
+	}
+}
+
+// truncateLines returns p without the bytes exceeding the maximum line
+// length, with the marker at the place of the dropped ones.
+// p itself is returned, without copying, if no line is truncated.
+func (r *RingBuffer) truncateLines(p []byte) []byte {
+	var out []byte
+	for i, c := range p {
+		switch {
+		case c == '\n':
+			r.lineLen = 0
+		case r.lineLen < r.maxLineLength:
+			r.lineLen++
+		default:
+			if out == nil {
+				out = append(make([]byte, 0, len(p)), p[:i]...)
+			}
+			// the marker is added once, the first time the line goes
+			// beyond the limit
+			if r.lineLen == r.maxLineLength {
+				out = append(out, r.lineMarker...)
+				r.lineLen++
+			}
+			continue
+		}
+
+		if out != nil {
+			out = append(out, c)
+		}
+	}
+
+	if out == nil {
+		return p
+	}
+	return out
+}
+
+// emitLines calls the OnLine callback for every line completed by p,
+// keeping the trailing partial line for the following writes.
+// Lines are always handed over from partialLine, never as slices of p: a
+// slice of p reaching the callback would make p escape to the heap, costing
+// an allocation to every Write even without a callback.
+func (r *RingBuffer) emitLines(p []byte) {
+	for {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			r.partialLine = append(r.partialLine, p...)
+			return
+		}
+
+		r.partialLine = append(r.partialLine, p[:i]...)
+		r.onLine(r.partialLine)
+		r.partialLine = r.partialLine[:0]
+		p = p[i+1:]
+	}
+}
+
+// midLineAfter reports whether the oldest byte held would not start a line
+// after discarding the first n bytes of the content followed by ps, i.e.
+// whether the last of them is not '\n'. It must be called before changing
+// the content; a n <= 0 discards nothing and keeps the current state.
+func (r *RingBuffer) midLineAfter(n int, ps ...[]byte) bool {
+	if n <= 0 {
+		return r.midLine
+	}
+
+	i, length := n-1, r.Len()
+	if i < length {
+		return r.at(i) != '\n'
+	}
+	i -= length
+	for _, p := range ps {
+		if i < len(p) {
+			return p[i] != '\n'
+		}
+		i -= len(p)
+	}
+	return r.midLine
+}
+
+// LastLines returns the last n complete lines held by the buffer, without
+// the trailing newline, from the oldest to the newest.
+// A line is complete when it is terminated by '\n', so the trailing partial
+// line being written is not returned. The first line is not returned either
+// if its beginning has been overwritten or drained.
+// The content is scanned backward from the end, stopping as soon as n lines
+// are found, so it is cheap even on large buffers.
+// It returns fewer than n lines if not enough are available.
+func (r *RingBuffer) LastLines(n int) []string {
+	if n <= 0 {
+		return nil
+	}
+
+	// skip the trailing partial line
+	end := r.lastIndexByte('\n', r.Len())
+	if end < 0 {
+		return nil
+	}
+
+	// n can be huge, e.g. to get all the lines, while each line takes at
+	// least one byte and its '\n'
+	lines := make([]string, 0, clamp(n, 0, r.Len()/2+1))
+	for len(lines) < n {
+		start := r.lastIndexByte('\n', end) + 1
+
+		// the first line is complete only if the byte discarded before it,
+		// if any, ended a line
+		if start == 0 && r.midLine {
+			break
+		}
+
+		line := make([]byte, end-start)
+		r.readAt(line, start)
+		lines = append(lines, string(line))
+
+		if start == 0 {
+			break
+		}
+		end = start - 1
+	}
+
+	// lines have been collected from the newest
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	return lines
+}
+
+// LineCount returns the number of complete lines held by the buffer, i.e.
+// the ones LastLines can return: a trailing partial line is not counted,
+// and neither is the first line if its beginning has been overwritten or
+// drained.
+// The lines are counted without copying the content.
+func (r *RingBuffer) LineCount() int {
+	first, second := r.Segments()
+	n := bytes.Count(first, []byte{'\n'}) + bytes.Count(second, []byte{'\n'})
+
+	// the first complete line lost its beginning
+	if n > 0 && r.midLine {
+		n--
+	}
+	return n
+}
+
+// lastIndexByte returns the logical index of the last occurrence of c in the
+// content before the logical index end, or -1 if not present.
+func (r *RingBuffer) lastIndexByte(c byte, end int) int {
+	for i := end - 1; i >= 0; i-- {
+		if r.at(i) == c {
+			return i
+		}
+	}
+	return -1
+}
+
+// SplitRecords returns the content held by the buffer split into records
+// terminated by delim, without the delimiter, from the oldest to the newest.
+// A trailing record not terminated by delim is returned as the last one,
+// and the first record may be missing its beginning if it has been
+// overwritten or drained.
+// The records are copies, so they stay valid after the buffer is modified.
+func (r *RingBuffer) SplitRecords(delim byte) [][]byte {
+	if r.Len() == 0 {
+		return nil
+	}
+
+	// the records share a single copy of the content, each one capped so
+	// that appending to it can't overwrite the following
+	content := make([]byte, r.Len())
+	r.readAt(content, 0)
+
+	records := make([][]byte, 0, bytes.Count(content, []byte{delim})+1)
+	for len(content) > 0 {
+		i := bytes.IndexByte(content, delim)
+		if i < 0 {
+			records = append(records, content[:len(content):len(content)])
+			break
+		}
+		records = append(records, content[:i:i])
+		content = content[i+1:]
+	}
+	return records
+}
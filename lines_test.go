@@ -0,0 +1,505 @@
+package ringbuffer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRingBuffer_LastLines(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		maxSize int
+		toWrite string
+		n       int
+		want    []string
+	}{
+		{
+			name:    "empty",
+			maxSize: 16,
+			toWrite: "",
+			n:       2,
+			want:    nil,
+		},
+		{
+			name:    "no complete line",
+			maxSize: 16,
+			toWrite: "partial",
+			n:       2,
+			want:    nil,
+		},
+		{
+			name:    "no ring, fewer lines than n",
+			maxSize: 16,
+			toWrite: "a\nbb\n",
+			n:       3,
+			want:    []string{"a", "bb"},
+		},
+		{
+			name:    "no ring, trailing partial line",
+			maxSize: 16,
+			toWrite: "a\nbb\nccc",
+			n:       1,
+			want:    []string{"bb"},
+		},
+		{
+			name:    "empty lines",
+			maxSize: 16,
+			toWrite: "a\n\n\n",
+			n:       2,
+			want:    []string{"", ""},
+		},
+		{
+			name:    "wrapped, leading partial line",
+			maxSize: 10,
+			toWrite: "first\nsecond\nthird\n",
+			n:       5,
+			want:    []string{"third"},
+		},
+		{
+			name:    "wrapped, line across the boundary",
+			maxSize: 10,
+			toWrite: "1111\n22\n333\n4\n",
+			n:       3,
+			want:    []string{"22", "333", "4"},
+		},
+		{
+			name:    "wrapped, fewer than available",
+			maxSize: 10,
+			toWrite: "1111\n22\n333\n4\n",
+			n:       2,
+			want:    []string{"333", "4"},
+		},
+		{
+			name:    "wrapped, first line exactly retained",
+			maxSize: 10,
+			toWrite: "xx\n22\n333\n4\n",
+			n:       5,
+			want:    []string{"22", "333", "4"},
+		},
+		{
+			name:    "wrapped at a line boundary",
+			maxSize: 4,
+			toWrite: "a\nb\nc\n",
+			n:       5,
+			want:    []string{"b", "c"},
+		},
+		{
+			name:    "all the lines",
+			maxSize: 10,
+			toWrite: "1111\n22\n333\n4\n",
+			n:       maxInt,
+			want:    []string{"22", "333", "4"},
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// write one byte at a time, so the content wraps physically
+			r := NewRingBuffer(0, tt.maxSize)
+			for i := range tt.toWrite {
+				_, _ = r.Write([]byte{tt.toWrite[i]})
+			}
+
+			got := r.LastLines(tt.n)
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LastLines() got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_LineCount(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		maxSize int
+		writes  []string
+		drain   int
+		want    int
+	}{
+		{name: "empty", maxSize: 16, want: 0},
+		{name: "no complete line", maxSize: 16, writes: []string{"partial"}, want: 0},
+		{name: "trailing newline", maxSize: 16, writes: []string{"a\nbb\n\n"}, want: 3},
+		{name: "trailing partial line", maxSize: 16, writes: []string{"a\nbb\nccc"}, want: 2},
+		{name: "wrapped, trailing newline", maxSize: 8, writes: []string{"aaa\nbb\n", "c\ndd\n"}, want: 3},
+		{name: "wrapped, trailing partial line", maxSize: 8, writes: []string{"aaa\nbb\n", "c\nddd"}, want: 2},
+		{name: "wrapped, first line cut", maxSize: 8, writes: []string{"aaaaaa\n", "b\nc\n"}, want: 2},
+		{name: "wrapped at a line boundary", maxSize: 4, writes: []string{"a\nb\n", "c\n"}, want: 2},
+		{name: "drained at a line boundary", maxSize: 16, writes: []string{"a\nb\nc\n"}, drain: 2, want: 2},
+		{name: "drained within a line", maxSize: 16, writes: []string{"aa\nb\nc\n"}, drain: 1, want: 2},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(0, tt.maxSize)
+			for _, w := range tt.writes {
+				_, _ = r.Write([]byte(w))
+			}
+			r.Drain(tt.drain)
+
+			got := r.LineCount()
+			if got != tt.want {
+				t.Errorf("LineCount() got = %d, want %d", got, tt.want)
+			}
+
+			// it matches the lines LastLines can return
+			if lines := r.LastLines(tt.maxSize); len(lines) != got {
+				t.Errorf("LineCount() got = %d, LastLines() returned %d lines", got, len(lines))
+			}
+		})
+	}
+}
+
+func TestRingBuffer_SplitRecords(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		maxSize int
+		delim   byte
+		writes  []string
+		want    [][]byte
+	}{
+		{name: "empty", maxSize: 16, delim: 0, want: nil},
+		{name: "no delimiter", maxSize: 16, delim: 0, writes: []string{"abc"}, want: [][]byte{[]byte("abc")}},
+		{name: "null delimited", maxSize: 16, delim: 0, writes: []string{"a\x00bb\x00\x00"}, want: [][]byte{[]byte("a"), []byte("bb"), {}}},
+		{name: "trailing partial record", maxSize: 16, delim: 0, writes: []string{"a\x00bb\x00ccc"}, want: [][]byte{[]byte("a"), []byte("bb"), []byte("ccc")}},
+		{name: "custom delimiter", maxSize: 16, delim: '|', writes: []string{"a|b\x00c|"}, want: [][]byte{[]byte("a"), []byte("b\x00c")}},
+		{name: "record spanning the ring boundary", maxSize: 8, delim: 0, writes: []string{"aa\x00bbb\x00", "cc\x00d"}, want: [][]byte{[]byte("bbb"), []byte("cc"), []byte("d")}},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(0, tt.maxSize)
+			for _, w := range tt.writes {
+				_, _ = r.Write([]byte(w))
+			}
+
+			got := r.SplitRecords(tt.delim)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitRecords() got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_SplitRecords_Copies(t *testing.T) {
+	t.Parallel()
+
+	r := NewRingBuffer(0, 8)
+	_, _ = r.Write([]byte("ab\x00cd"))
+
+	got := r.SplitRecords(0)
+	got[0] = append(got[0], 'x')
+	_, _ = r.Write([]byte("efghijkl"))
+
+	want := [][]byte{[]byte("abx"), []byte("cd")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SplitRecords() got = %q, want %q", got, want)
+	}
+}
+
+func TestRingBuffer_OnLine(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		writes []string
+		want   []string
+	}{
+		{
+			name:   "no line",
+			writes: []string{"partial"},
+			want:   nil,
+		},
+		{
+			name:   "one line per write",
+			writes: []string{"a\n", "bb\n"},
+			want:   []string{"a", "bb"},
+		},
+		{
+			name:   "many lines in one write",
+			writes: []string{"a\nbb\n\nccc\nparti"},
+			want:   []string{"a", "bb", "", "ccc"},
+		},
+		{
+			name:   "fragments",
+			writes: []string{"he", "llo", " wor", "ld\nfoo", "\n"},
+			want:   []string{"hello world", "foo"},
+		},
+		{
+			name:   "longer than the buffer",
+			writes: []string{"0123456789", "abcdef\n"},
+			want:   []string{"0123456789abcdef"},
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got []string
+			r := NewRingBuffer(0, 8)
+			r.OnLine(func(line []byte) {
+				got = append(got, string(line))
+			})
+
+			for _, w := range tt.writes {
+				_, _ = r.Write([]byte(w))
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("OnLine() got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_OnLine_WriteMulti(t *testing.T) {
+	t.Parallel()
+
+	var got []string
+	r := NewRingBuffer(0, 4)
+	r.OnLine(func(line []byte) {
+		got = append(got, string(line))
+	})
+
+	_, _ = r.WriteMulti([]byte("first\nsec"), []byte("ond\n"), []byte("x"))
+
+	want := []string{"first", "second"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("OnLine() got = %q, want %q", got, want)
+	}
+}
+
+func TestWithMaxLineLength(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		n      int
+		marker string
+		writes []string
+		want   string
+		wantN  int
+	}{
+		{
+			name:   "short lines",
+			n:      4,
+			marker: "...",
+			writes: []string{"ab\ncd\n"},
+			want:   "ab\ncd\n",
+			wantN:  6,
+		},
+		{
+			name:   "exactly the limit",
+			n:      4,
+			marker: "...",
+			writes: []string{"abcd\n"},
+			want:   "abcd\n",
+			wantN:  5,
+		},
+		{
+			name:   "overlong line",
+			n:      4,
+			marker: "...",
+			writes: []string{"abcdefghij\nxy\n"},
+			want:   "abcd...\nxy\n",
+			wantN:  14,
+		},
+		{
+			name:   "without marker",
+			n:      4,
+			writes: []string{"abcdefghij\nxy"},
+			want:   "abcd\nxy",
+			wantN:  13,
+		},
+		{
+			name:   "across writes",
+			n:      4,
+			marker: "~",
+			writes: []string{"ab", "cd", "ef", "gh\n", "ijklmn"},
+			want:   "abcd~\nijkl~",
+			wantN:  15,
+		},
+		{
+			name:   "disabled",
+			n:      0,
+			marker: "...",
+			writes: []string{"abcdefghij\n"},
+			want:   "abcdefghij\n",
+			wantN:  11,
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(0, 64, WithMaxLineLength(tt.n, tt.marker))
+
+			gotN := 0
+			for _, w := range tt.writes {
+				n, err := r.Write([]byte(w))
+				if err != nil {
+					t.Fatalf("Write() error = %v", err)
+				}
+				gotN += n
+			}
+
+			if gotN != tt.wantN {
+				t.Errorf("Write() got = %d, want %d", gotN, tt.wantN)
+			}
+			if got := r.String(); got != tt.want {
+				t.Errorf("String() got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithMaxLineLength_OnLine(t *testing.T) {
+	t.Parallel()
+
+	var got []string
+	r := NewRingBuffer(0, 64, WithMaxLineLength(3, "..."))
+	r.OnLine(func(line []byte) {
+		got = append(got, string(line))
+	})
+
+	_, _ = r.WriteMulti([]byte("abcdef\nx"), []byte("yzw\n"))
+
+	want := []string{"abc...", "xyz..."}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("OnLine() got = %q, want %q", got, want)
+	}
+}
+
+func TestRingBuffer_midLine(t *testing.T) {
+	t.Parallel()
+
+	// a step writes data with the method named by op, or calls it with n
+	type step struct {
+		op   string
+		data []string
+		n    int
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "Write",
+			steps: []step{
+				{op: "Write", data: []string{"ab\ncd\n"}},
+				{op: "Write", data: []string{"e\nf"}},
+				{op: "Write", data: []string{"\ng"}},
+				{op: "Write", data: []string{"hij\nklmnop\n"}},
+			},
+		},
+		{
+			name: "Write larger than maxSize",
+			steps: []step{
+				{op: "Write", data: []string{"a\nbcdefghi"}},
+				{op: "Write", data: []string{"abcdefg\nhijklmn"}},
+			},
+		},
+		{
+			name: "WriteWith",
+			steps: []step{
+				{op: "Write", data: []string{"a\nbcdefg"}},
+				{op: "Write", data: []string{"h"}},
+				{op: "WriteWith", data: []string{"x"}},
+				{op: "WriteWith", data: []string{"yz"}},
+				{op: "WriteWith", data: []string{"\nw"}},
+				{op: "WriteWith", data: []string{"\n"}},
+			},
+		},
+		{
+			name: "WriteMulti",
+			steps: []step{
+				{op: "Write", data: []string{"ab"}},
+				{op: "WriteMulti", data: []string{"c\nd", "efgh\n", "ij\nkl", "mnop"}},
+				{op: "WriteMulti", data: []string{"q", "rs\n", "tuvwxyz\n"}},
+			},
+		},
+		{
+			name: "Drain",
+			steps: []step{
+				{op: "Write", data: []string{"a\nbc\nd"}},
+				{op: "Drain", n: 2},
+				{op: "Drain", n: 1},
+				{op: "Write", data: []string{"efgh\nijk"}},
+				{op: "Drain", n: 5},
+			},
+		},
+		{
+			name: "SetMaxSize",
+			steps: []step{
+				{op: "Write", data: []string{"ab\ncdef"}},
+				{op: "SetMaxSize", n: 5},
+				{op: "SetMaxSize", n: 4},
+				{op: "Write", data: []string{"gh\n"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// the stream of every byte written tells the one before the
+			// oldest byte held
+			var stream []byte
+
+			r := NewRingBuffer(0, 8)
+			for i, st := range tt.steps {
+				switch st.op {
+				case "Write":
+					_, _ = r.Write([]byte(st.data[0]))
+				case "WriteWith":
+					// more space than needed is reserved, so not all of it
+					// is used
+					_, _ = r.WriteWith(len(st.data[0])+2, func(dst []byte) int {
+						return copy(dst, st.data[0])
+					})
+				case "WriteMulti":
+					ps := make([][]byte, len(st.data))
+					for j := range st.data {
+						ps[j] = []byte(st.data[j])
+					}
+					_, _ = r.WriteMulti(ps...)
+				case "Drain":
+					r.Drain(st.n)
+				case "SetMaxSize":
+					_ = r.SetMaxSize(st.n)
+				}
+				for _, d := range st.data {
+					stream = append(stream, d...)
+				}
+
+				oldest := r.OldestOffset()
+				want := oldest > 0 && stream[oldest-1] != '\n'
+				if r.midLine != want {
+					t.Errorf("step %d: midLine got = %v, want %v", i, r.midLine, want)
+				}
+			}
+		})
+	}
+}
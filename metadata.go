@@ -0,0 +1,27 @@
+package ringbuffer
+
+// WithMetadata attaches the value to the buffer under key, as SetMeta does.
+func WithMetadata(key string, value interface{}) Option {
+	return func(r *RingBuffer) {
+		r.SetMeta(key, value)
+	}
+}
+
+// SetMeta attaches an arbitrary value to the buffer under key, replacing
+// the previous one, e.g. so that a registry can track buffers by their
+// attributes without a side map keyed by the buffer pointer.
+// The metadata are never used by the buffer itself, and they are dropped by
+// Close and ResetFull.
+func (r *RingBuffer) SetMeta(key string, value interface{}) {
+	if r.meta == nil {
+		r.meta = make(map[string]interface{})
+	}
+	r.meta[key] = value
+}
+
+// Meta returns the value attached to the buffer under key, and whether
+// there is one.
+func (r *RingBuffer) Meta(key string) (interface{}, bool) {
+	v, ok := r.meta[key]
+	return v, ok
+}
@@ -0,0 +1,89 @@
+package ringbuffer
+
+import "testing"
+
+func TestRingBuffer_Meta(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		opts   []Option
+		steps  func(r *RingBuffer)
+		key    string
+		want   interface{}
+		wantOk bool
+	}{
+		{name: "unset", key: "tenant", want: nil, wantOk: false},
+		{name: "option", opts: []Option{WithMetadata("tenant", "acme")}, key: "tenant", want: "acme", wantOk: true},
+		{
+			name:   "set",
+			steps:  func(r *RingBuffer) { r.SetMeta("shard", 3) },
+			key:    "shard",
+			want:   3,
+			wantOk: true,
+		},
+		{
+			name:   "overwritten",
+			opts:   []Option{WithMetadata("tenant", "acme")},
+			steps:  func(r *RingBuffer) { r.SetMeta("tenant", "globex") },
+			key:    "tenant",
+			want:   "globex",
+			wantOk: true,
+		},
+		{
+			name:   "nil value",
+			steps:  func(r *RingBuffer) { r.SetMeta("tenant", nil) },
+			key:    "tenant",
+			want:   nil,
+			wantOk: true,
+		},
+		{
+			name: "other key",
+			opts: []Option{WithMetadata("tenant", "acme")},
+			key:  "shard",
+			want: nil,
+		},
+		{
+			name: "closed",
+			opts: []Option{WithMetadata("tenant", "acme")},
+			steps: func(r *RingBuffer) {
+				r.SetMeta("shard", 3)
+				_ = r.Close()
+			},
+			key:  "tenant",
+			want: nil,
+		},
+		{
+			name:  "full reset",
+			opts:  []Option{WithMetadata("tenant", "acme")},
+			steps: func(r *RingBuffer) { r.ResetFull() },
+			key:   "tenant",
+			want:  nil,
+		},
+		{
+			name:   "kept by Reset",
+			opts:   []Option{WithMetadata("tenant", "acme")},
+			steps:  func(r *RingBuffer) { r.Reset() },
+			key:    "tenant",
+			want:   "acme",
+			wantOk: true,
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(0, 8, tt.opts...)
+			if tt.steps != nil {
+				tt.steps(r)
+			}
+
+			got, ok := r.Meta(tt.key)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("Meta() got = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
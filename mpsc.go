@@ -0,0 +1,173 @@
+package ringbuffer
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// MPSCRingBuffer is a RingBuffer safe for concurrent use by many producers
+// writing and a single consumer reading.
+// Each Write is applied atomically, so the content of different writes is
+// never interleaved, and when the maximum size is reached the oldest content
+// is overwritten as usual.
+// Producers never wait for the consumer to process the data: the consumer
+// holds the lock only for the time needed to copy the content out.
+type MPSCRingBuffer struct {
+	// seq is the first field to be 64-bit aligned for atomic operations
+	seq uint64
+
+	mu sync.Mutex
+	rb *RingBuffer
+}
+
+// interfaces implemented by MPSCRingBuffer
+var (
+	_ io.Writer   = (*MPSCRingBuffer)(nil)
+	_ io.Reader   = (*MPSCRingBuffer)(nil)
+	_ io.Closer   = (*MPSCRingBuffer)(nil)
+	_ io.WriterTo = (*MPSCRingBuffer)(nil)
+)
+
+// NewMPSCRingBuffer creates and initialise a new MPSCRingBuffer with the same
+// parameters of NewRingBuffer.
+func NewMPSCRingBuffer(initialSize, maxSize int, opts ...Option) *MPSCRingBuffer {
+	return &MPSCRingBuffer{
+		rb: NewRingBuffer(initialSize, maxSize, opts...),
+	}
+}
+
+// Write appends the contents of p to the buffer, like RingBuffer.Write.
+// It is safe to call it from many goroutines.
+func (m *MPSCRingBuffer) Write(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n, err := m.rb.Write(p)
+	if n > 0 {
+		m.bump()
+	}
+	return n, err
+}
+
+// Read reads and consumes the oldest len(p) bytes, like RingBuffer.Read.
+func (m *MPSCRingBuffer) Read(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n, err := m.rb.Read(p)
+	if n > 0 {
+		m.bump()
+	}
+	return n, err
+}
+
+// WriteTo consumes the whole content and writes it to w.
+// The content is copied out while holding the lock, and written to w only
+// after releasing it, so a slow w never blocks producers.
+// Because of that, the part of the content not accepted by w is lost.
+func (m *MPSCRingBuffer) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	content := m.rb.Bytes()
+	if m.rb.Drain(len(content)) > 0 {
+		m.bump()
+	}
+	m.mu.Unlock()
+
+	if len(content) == 0 {
+		return 0, nil
+	}
+
+	n, err := w.Write(content)
+	if err == nil && n < len(content) {
+		err = io.ErrShortWrite
+	}
+	return int64(n), err
+}
+
+// Bytes returns a copy of the buffer content in a slice of bytes.
+func (m *MPSCRingBuffer) Bytes() []byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.rb.Bytes()
+}
+
+// TakeBytes returns a copy of the buffer content and clears the buffer,
+// like RingBuffer.TakeBytes, atomically: every write ends up either in the
+// returned content or in the buffer after the call.
+func (m *MPSCRingBuffer) TakeBytes() []byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.bump()
+	return m.rb.TakeBytes()
+}
+
+// String returns the buffer content as a string.
+func (m *MPSCRingBuffer) String() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.rb.String()
+}
+
+// Len returns the number of bytes of content currently held by the buffer.
+func (m *MPSCRingBuffer) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.rb.Len()
+}
+
+// Cap returns the actual size of memory allocated for the underlying buffer.
+func (m *MPSCRingBuffer) Cap() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.rb.Cap()
+}
+
+// Written returns the number of bytes written so far in the buffer.
+func (m *MPSCRingBuffer) Written() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.rb.Written()
+}
+
+// Close removes any reference of the underlying slice letting the memory be
+// freed, like RingBuffer.Close.
+func (m *MPSCRingBuffer) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.bump()
+	return m.rb.Close()
+}
+
+// Stats returns a snapshot of the buffer size and usage counters, like
+// RingBuffer.Stats.
+func (m *MPSCRingBuffer) Stats() Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.rb.Stats()
+}
+
+// Seq returns a sequence number increased by every change of the content.
+// It doesn't take the lock, so it is cheap to poll.
+func (m *MPSCRingBuffer) Seq() uint64 {
+	return atomic.LoadUint64(&m.seq)
+}
+
+// ReadConsistent calls fn with a snapshot of the content, and reports
+// whether the buffer is still unchanged when fn returns.
+// The lock is held only to copy the content out, so fn never blocks the
+// producers: when it returns false, the result computed by fn is already
+// stale and the caller can retry.
+// The slice passed to fn is valid only during the call.
+func (m *MPSCRingBuffer) ReadConsistent(fn func(content []byte)) bool {
+	m.mu.Lock()
+	seq := m.Seq()
+	content := m.rb.Bytes()
+	m.mu.Unlock()
+
+	fn(content)
+	return m.Seq() == seq
+}
+
+// bump increases the sequence number. It must be called holding the lock.
+func (m *MPSCRingBuffer) bump() {
+	atomic.AddUint64(&m.seq, 1)
+}
@@ -0,0 +1,241 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestMPSCRingBuffer(t *testing.T) {
+	t.Parallel()
+
+	const (
+		producers  = 32
+		records    = 500
+		recordSize = 8
+		maxSize    = 64 * recordSize
+	)
+
+	m := NewMPSCRingBuffer(0, maxSize)
+
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < records; i++ {
+				// fixed size records: "pp:iiii\n"
+				_, _ = fmt.Fprintf(m, "%02d:%04d\n", p, i)
+			}
+		}(p)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	var consumed bytes.Buffer
+	for stop := false; !stop; {
+		select {
+		case <-done:
+			stop = true
+		default:
+		}
+
+		if _, err := m.WriteTo(&consumed); err != nil {
+			t.Fatalf("WriteTo() error = %v", err)
+		}
+		if got := m.Cap(); got > maxSize {
+			t.Fatalf("Cap() got = %d, want <= %d", got, maxSize)
+		}
+	}
+
+	if got := m.Written(); got != producers*records*recordSize {
+		t.Errorf("Written() got = %d, want %d", got, producers*records*recordSize)
+	}
+
+	// maxSize is a multiple of the record size, so only whole records
+	// can be evicted and every consumed record must be intact and in order
+	// for its producer
+	out := consumed.Bytes()
+	if len(out)%recordSize != 0 {
+		t.Fatalf("consumed %d bytes, not a multiple of the record size", len(out))
+	}
+
+	last := make(map[int]int)
+	for ; len(out) > 0; out = out[recordSize:] {
+		var p, i int
+		if _, err := fmt.Sscanf(string(out[:recordSize]), "%02d:%04d\n", &p, &i); err != nil {
+			t.Fatalf("corrupted record %q: %v", out[:recordSize], err)
+		}
+		if prev, ok := last[p]; ok && i <= prev {
+			t.Fatalf("record %d of producer %d consumed after record %d", i, p, prev)
+		}
+		last[p] = i
+	}
+}
+
+func TestMPSCRingBuffer_Read(t *testing.T) {
+	t.Parallel()
+
+	m := NewMPSCRingBuffer(0, 4)
+	_, _ = m.Write([]byte("abcdef"))
+
+	p := make([]byte, 3)
+	n, err := m.Read(p)
+	if err != nil {
+		t.Errorf("Read() error = %v", err)
+	}
+	if got := string(p[:n]); got != "cde" {
+		t.Errorf("Read() got = %q, want %q", got, "cde")
+	}
+	if got := m.String(); got != "f" {
+		t.Errorf("String() got = %q, want %q", got, "f")
+	}
+}
+
+func TestMPSCRingBuffer_Seq(t *testing.T) {
+	t.Parallel()
+
+	m := NewMPSCRingBuffer(0, 4)
+	seq := m.Seq()
+
+	steps := []struct {
+		name    string
+		do      func()
+		changed bool
+	}{
+		{name: "write", do: func() { _, _ = m.Write([]byte("abcdef")) }, changed: true},
+		{name: "empty write", do: func() { _, _ = m.Write(nil) }, changed: false},
+		{name: "read only", do: func() { _ = m.Bytes() }, changed: false},
+		{name: "read", do: func() { _, _ = m.Read(make([]byte, 2)) }, changed: true},
+		{name: "write to", do: func() { _, _ = m.WriteTo(&bytes.Buffer{}) }, changed: true},
+		{name: "write to empty", do: func() { _, _ = m.WriteTo(&bytes.Buffer{}) }, changed: false},
+		{name: "close", do: func() { _ = m.Close() }, changed: true},
+	}
+	for _, s := range steps {
+		s.do()
+		got := m.Seq()
+		if (got != seq) != s.changed {
+			t.Errorf("%s: Seq() got = %d, previous %d, want changed %v", s.name, got, seq, s.changed)
+		}
+		seq = got
+	}
+}
+
+func TestMPSCRingBuffer_ReadConsistent(t *testing.T) {
+	t.Parallel()
+
+	const (
+		producers  = 8
+		records    = 2000
+		recordSize = 8
+		maxSize    = 16 * recordSize
+	)
+
+	m := NewMPSCRingBuffer(0, maxSize)
+
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			record := bytes.Repeat([]byte{'a' + byte(p)}, recordSize)
+			for i := 0; i < records; i++ {
+				_, _ = m.Write(record)
+			}
+		}(p)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	retries := 0
+	for stop := false; !stop; {
+		select {
+		case <-done:
+			stop = true
+		default:
+		}
+
+		var snapshot []byte
+		for !m.ReadConsistent(func(content []byte) {
+			snapshot = append(snapshot[:0], content...)
+		}) {
+			retries++
+		}
+
+		// every snapshot is made of whole records, each written by a
+		// single producer
+		if len(snapshot)%recordSize != 0 {
+			t.Fatalf("snapshot of %d bytes, not a multiple of the record size", len(snapshot))
+		}
+		for s := snapshot; len(s) > 0; s = s[recordSize:] {
+			if !bytes.Equal(s[:recordSize], bytes.Repeat(s[:1], recordSize)) {
+				t.Fatalf("corrupted record %q", s[:recordSize])
+			}
+		}
+	}
+	t.Logf("%d retries", retries)
+
+	if !m.ReadConsistent(func([]byte) {}) {
+		t.Errorf("ReadConsistent() got = false with no writers, want true")
+	}
+}
+
+func TestMPSCRingBuffer_TakeBytes(t *testing.T) {
+	t.Parallel()
+
+	const (
+		producers = 8
+		records   = 1000
+	)
+
+	// large enough to never evict, so every byte written must be taken once
+	m := NewMPSCRingBuffer(0, producers*records)
+
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < records; i++ {
+				_, _ = m.Write([]byte{'a' + byte(p)})
+			}
+		}(p)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	counts := make(map[byte]int)
+	for stop := false; !stop; {
+		select {
+		case <-done:
+			stop = true
+		default:
+		}
+
+		for _, c := range m.TakeBytes() {
+			counts[c]++
+		}
+	}
+
+	for p := 0; p < producers; p++ {
+		if got := counts['a'+byte(p)]; got != records {
+			t.Errorf("taken %d bytes of producer %d, want %d", got, p, records)
+		}
+	}
+	if got := m.Len(); got != 0 {
+		t.Errorf("Len() got = %d, want 0", got)
+	}
+}
@@ -0,0 +1,55 @@
+package ringbuffer
+
+// Option configures optional behaviours of a RingBuffer at creation time.
+type Option func(*RingBuffer)
+
+// OverflowPolicy defines what Write does with a single write larger than the
+// maximum size of the buffer.
+type OverflowPolicy int
+
+const (
+	// OverflowTruncate keeps only the last maxSize bytes of the write.
+	// It is the default policy.
+	OverflowTruncate OverflowPolicy = iota
+
+	// OverflowReject refuses the whole write with ErrRecordTooLarge, leaving
+	// the buffer untouched, so partial records are never stored.
+	OverflowReject
+)
+
+// WithOverflowPolicy sets the policy applied to writes larger than maxSize.
+func WithOverflowPolicy(p OverflowPolicy) Option {
+	return func(r *RingBuffer) {
+		r.overflowPolicy = p
+	}
+}
+
+// WithLazyFull makes the buffer skip the dynamic array phase: the first
+// write that needs more space allocates the whole maxSize at once, instead
+// of growing it step by step.
+// It is useful for buffers almost always filled up, when allocating maxSize
+// in the constructor would waste memory for the ones never used.
+func WithLazyFull() Option {
+	return func(r *RingBuffer) {
+		r.lazyFull = true
+	}
+}
+
+// WithMaxWriteSize limits the size of a single write: Write rejects a larger
+// one with ErrWriteTooLarge, leaving the buffer untouched, so a single huge
+// write can't wipe out the whole content.
+// A limit lower than or equal to 0, the default, means no limit.
+func WithMaxWriteSize(n int) Option {
+	return func(r *RingBuffer) {
+		r.maxWriteSize = n
+	}
+}
+
+// MaxWriteSize returns the limit on the size of a single write set with
+// WithMaxWriteSize, or 0 if there is none.
+func (r *RingBuffer) MaxWriteSize() int {
+	if r.maxWriteSize < 0 {
+		return 0
+	}
+	return r.maxWriteSize
+}
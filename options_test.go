@@ -0,0 +1,289 @@
+package ringbuffer
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestWithOverflowPolicy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		policy     OverflowPolicy
+		prefill    string
+		toWrite    []byte
+		wantN      int
+		wantErr    error
+		wantBuffer *RingBuffer
+	}{
+		{
+			name:    "truncate, oversized record",
+			policy:  OverflowTruncate,
+			prefill: "ab",
+			toWrite: []byte("123456"),
+			wantN:   6,
+			wantBuffer: &RingBuffer{
+				buf:            []byte{'3', '4', '5', '6'},
+				pos:            0,
+				written:        8,
+				ringMode:       true,
+				maxSize:        4,
+				overflowPolicy: OverflowTruncate,
+				midLine:        true,
+			},
+		},
+		{
+			name:    "reject, oversized record",
+			policy:  OverflowReject,
+			prefill: "ab",
+			toWrite: []byte("123456"),
+			wantN:   0,
+			wantErr: ErrRecordTooLarge,
+			wantBuffer: &RingBuffer{
+				buf:            []byte{'a', 'b', 0, 0},
+				pos:            2,
+				written:        2,
+				ringMode:       false,
+				maxSize:        4,
+				overflowPolicy: OverflowReject,
+			},
+		},
+		{
+			name:    "reject, record fitting maxSize",
+			policy:  OverflowReject,
+			prefill: "ab",
+			toWrite: []byte("1234"),
+			wantN:   4,
+			wantBuffer: &RingBuffer{
+				buf:            []byte{'3', '4', '1', '2'},
+				pos:            2,
+				written:        6,
+				ringMode:       true,
+				maxSize:        4,
+				overflowPolicy: OverflowReject,
+				midLine:        true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(4, 4, WithOverflowPolicy(tt.policy))
+			_, _ = r.Write([]byte(tt.prefill))
+
+			gotN, err := r.Write(tt.toWrite)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Write() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if gotN != tt.wantN {
+				t.Errorf("Write() got = %d, want %d", gotN, tt.wantN)
+			}
+
+			if !reflect.DeepEqual(r, tt.wantBuffer) {
+				t.Errorf("Write() got = %+v want %+v", r, tt.wantBuffer)
+			}
+		})
+	}
+}
+
+func TestWithLazyFull(t *testing.T) {
+	t.Parallel()
+
+	r := NewRingBuffer(0, 1024, WithLazyFull())
+	if got := r.Cap(); got != 0 {
+		t.Errorf("Cap() before writing got = %d, want 0", got)
+	}
+
+	_, _ = r.Write([]byte("a"))
+	if got := r.Cap(); got != 1024 {
+		t.Errorf("Cap() after first write got = %d, want 1024", got)
+	}
+
+	_, _ = r.Write(make([]byte, 2000))
+	if got := r.Cap(); got != 1024 {
+		t.Errorf("Cap() after ring mode got = %d, want 1024", got)
+	}
+}
+
+func TestWithLazyFull_Allocations(t *testing.T) {
+	p := make([]byte, 10)
+
+	create := testing.AllocsPerRun(100, func() {
+		_ = NewRingBuffer(0, 1024, WithLazyFull())
+	})
+	createAndWrite := testing.AllocsPerRun(100, func() {
+		r := NewRingBuffer(0, 1024, WithLazyFull())
+		for i := 0; i < 200; i++ {
+			_, _ = r.Write(p)
+		}
+	})
+
+	if got := createAndWrite - create; got != 1 {
+		t.Errorf("allocations for writing got = %v, want 1", got)
+	}
+}
+
+func TestRingBuffer_WriteN(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		policy  OverflowPolicy
+		prefill string
+		toWrite string
+		wantN   int
+		wantErr error
+		want    string
+	}{
+		{
+			name:    "reject, fitting",
+			policy:  OverflowReject,
+			prefill: "ab",
+			toWrite: "cd",
+			wantN:   2,
+			want:    "abcd",
+		},
+		{
+			name:    "reject, partially fitting",
+			policy:  OverflowReject,
+			prefill: "ab",
+			toWrite: "cdef",
+			wantN:   2,
+			wantErr: io.ErrShortWrite,
+			want:    "abcd",
+		},
+		{
+			name:    "reject, full",
+			policy:  OverflowReject,
+			prefill: "abcd",
+			toWrite: "e",
+			wantN:   0,
+			wantErr: io.ErrShortWrite,
+			want:    "abcd",
+		},
+		{
+			name:    "reject, larger than maxSize",
+			policy:  OverflowReject,
+			toWrite: "abcdef",
+			wantN:   4,
+			wantErr: io.ErrShortWrite,
+			want:    "abcd",
+		},
+		{
+			name:    "truncate, like Write",
+			policy:  OverflowTruncate,
+			prefill: "ab",
+			toWrite: "cdef",
+			wantN:   4,
+			want:    "cdef",
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(0, 4, WithOverflowPolicy(tt.policy))
+			_, _ = r.Write([]byte(tt.prefill))
+
+			gotN, err := r.WriteN([]byte(tt.toWrite))
+
+			if err != tt.wantErr {
+				t.Errorf("WriteN() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if gotN != tt.wantN {
+				t.Errorf("WriteN() got = %d, want %d", gotN, tt.wantN)
+			}
+			if got := r.String(); got != tt.want {
+				t.Errorf("String() got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithMaxWriteSize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		toWrite string
+		multi   bool
+		wantN   int
+		wantErr error
+		want    string
+	}{
+		{
+			name:    "under the limit",
+			toWrite: "xy",
+			wantN:   2,
+			want:    "abcdxy",
+		},
+		{
+			name:    "equal to the limit",
+			toWrite: "xyz",
+			wantN:   3,
+			want:    "abcdxyz",
+		},
+		{
+			name:    "over the limit",
+			toWrite: "wxyz",
+			wantN:   0,
+			wantErr: ErrWriteTooLarge,
+			want:    "abcd",
+		},
+		{
+			name:    "over the limit, in a batch",
+			toWrite: "wxyz",
+			multi:   true,
+			wantN:   1,
+			wantErr: ErrWriteTooLarge,
+			want:    "abcd1",
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(0, 8, WithMaxWriteSize(3))
+			_, _ = r.Write([]byte("ab"))
+			_, _ = r.Write([]byte("cd"))
+			if got := r.MaxWriteSize(); got != 3 {
+				t.Errorf("MaxWriteSize() got = %d, want 3", got)
+			}
+
+			var (
+				n   int
+				err error
+			)
+			if tt.multi {
+				n, err = r.WriteMulti([]byte("1"), []byte(tt.toWrite))
+			} else {
+				n, err = r.Write([]byte(tt.toWrite))
+			}
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Write() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if n != tt.wantN {
+				t.Errorf("Write() got = %d, want %d", n, tt.wantN)
+			}
+			if got := r.String(); got != tt.want {
+				t.Errorf("String() got = %q, want %q", got, tt.want)
+			}
+			if got := r.Written(); got != len(tt.want) {
+				t.Errorf("Written() got = %d, want %d", got, len(tt.want))
+			}
+		})
+	}
+}
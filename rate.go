@@ -0,0 +1,112 @@
+package ringbuffer
+
+import (
+	"math"
+	"time"
+)
+
+// WithWriteRate enables the tracking of the write rate, exposed by WriteRate.
+// The rate is an exponentially-weighted moving average over the given
+// window: writes older than the window have less and less weight.
+// It is disabled by default, to avoid reading the clock on every Write.
+func WithWriteRate(window time.Duration) Option {
+	return func(r *RingBuffer) {
+		r.rateWindow = window
+	}
+}
+
+// WriteRate returns the moving average of the bytes written per second.
+// It always returns 0 if the tracking has not been enabled with
+// WithWriteRate.
+func (r *RingBuffer) WriteRate() float64 {
+	if r.rateWindow <= 0 || r.rateLast.IsZero() {
+		return 0
+	}
+	return r.rate * r.rateDecay(r.clock())
+}
+
+// trackRate updates the write rate with a write of n bytes.
+// Every byte adds 1/window to the rate, while the previous value decays
+// exponentially with the time elapsed, so a steady input converges to its
+// rate in bytes per second.
+func (r *RingBuffer) trackRate(n int) {
+	now := r.clock()
+	if !r.rateLast.IsZero() {
+		r.rate *= r.rateDecay(now)
+	}
+	r.rate += float64(n) / r.rateWindow.Seconds()
+	r.rateLast = now
+}
+
+// rateDecay returns the factor applied to the rate for the time elapsed from
+// the last write to now.
+func (r *RingBuffer) rateDecay(now time.Time) float64 {
+	elapsed := now.Sub(r.rateLast)
+	return math.Exp(-elapsed.Seconds() / r.rateWindow.Seconds())
+}
+
+// throughputTracker counts the bytes written since the first write.
+type throughputTracker struct {
+	start time.Time
+	bytes int64
+}
+
+// WithThroughput enables the tracking of the average throughput since the
+// first write, exposed by Throughput and ETA, e.g. to report the progress
+// of a buffer being filled from a source of known size.
+// Unlike WithWriteRate, every write has the same weight.
+func WithThroughput() Option {
+	return func(r *RingBuffer) {
+		r.throughput = &throughputTracker{}
+	}
+}
+
+// Throughput returns the average number of bytes written per second since
+// the first write. It returns 0 before the first write, or if the tracking
+// has not been enabled with WithThroughput.
+func (r *RingBuffer) Throughput() float64 {
+	if r.throughput == nil || r.throughput.start.IsZero() {
+		return 0
+	}
+
+	elapsed := r.clock().Sub(r.throughput.start)
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(r.throughput.bytes) / elapsed.Seconds()
+}
+
+// ETA returns the time needed to have written total bytes since the first
+// write, at the current Throughput. It returns 0 if total bytes have
+// already been written, and -1 if it can't be estimated because nothing
+// has been measured yet.
+func (r *RingBuffer) ETA(total int64) time.Duration {
+	if r.throughput != nil && r.throughput.bytes >= total {
+		return 0
+	}
+
+	rate := r.Throughput()
+	if rate <= 0 {
+		return -1
+	}
+
+	left := float64(total - r.throughput.bytes)
+	return time.Duration(left / rate * float64(time.Second))
+}
+
+// trackThroughput counts a write of n bytes, starting the clock at the
+// first one.
+func (r *RingBuffer) trackThroughput(n int) {
+	if r.throughput.start.IsZero() {
+		r.throughput.start = r.clock()
+	}
+	r.throughput.bytes += int64(n)
+}
+
+// clock returns the current time, using the monotonic clock.
+func (r *RingBuffer) clock() time.Time {
+	if r.now != nil {
+		return r.now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,125 @@
+package ringbuffer
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock.
+type fakeClock struct {
+	t time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	return f.t
+}
+
+func (f *fakeClock) Advance(d time.Duration) {
+	f.t = f.t.Add(d)
+}
+
+func TestRingBuffer_WriteRate(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	r := NewRingBuffer(0, 1024, WithWriteRate(100*time.Millisecond))
+	r.now = clock.Now
+
+	if got := r.WriteRate(); got != 0 {
+		t.Errorf("WriteRate() before any write got = %v, want 0", got)
+	}
+
+	withinTolerance := func(got, want float64) bool {
+		return math.Abs(got-want) <= want*0.1
+	}
+
+	// steady: 100 bytes every 10ms, 10KB/s
+	steady := make([]byte, 100)
+	for i := 0; i < 100; i++ {
+		clock.Advance(10 * time.Millisecond)
+		_, _ = r.Write(steady)
+	}
+	if got := r.WriteRate(); !withinTolerance(got, 10000) {
+		t.Errorf("WriteRate() with steady input got = %v, want ~10000", got)
+	}
+
+	// burst: 10000 bytes every 10ms, 1MB/s
+	burst := make([]byte, 10000)
+	for i := 0; i < 100; i++ {
+		clock.Advance(10 * time.Millisecond)
+		_, _ = r.Write(burst)
+	}
+	if got := r.WriteRate(); !withinTolerance(got, 1000000) {
+		t.Errorf("WriteRate() with bursty input got = %v, want ~1000000", got)
+	}
+
+	// idle: the rate decays to almost zero
+	clock.Advance(time.Second)
+	if got := r.WriteRate(); got > 100 {
+		t.Errorf("WriteRate() after idle got = %v, want ~0", got)
+	}
+}
+
+func TestRingBuffer_WriteRate_Disabled(t *testing.T) {
+	t.Parallel()
+
+	r := NewRingBuffer(0, 1024)
+	_, _ = r.Write([]byte("abc"))
+
+	if got := r.WriteRate(); got != 0 {
+		t.Errorf("WriteRate() got = %v, want 0", got)
+	}
+	if !r.rateLast.IsZero() {
+		t.Errorf("rate tracked while disabled")
+	}
+}
+
+func TestRingBuffer_Throughput(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	r := NewRingBuffer(0, 1024, WithThroughput())
+	r.now = clock.Now
+
+	if got := r.Throughput(); got != 0 {
+		t.Errorf("Throughput() before any write got = %v, want 0", got)
+	}
+	if got := r.ETA(1000); got != -1 {
+		t.Errorf("ETA() before any write got = %v, want -1", got)
+	}
+
+	withinTolerance := func(got, want float64) bool {
+		return math.Abs(got-want) <= want*0.05
+	}
+
+	// steady: 100 bytes every 10ms, 10KB/s, for 1s
+	steady := make([]byte, 100)
+	_, _ = r.Write(steady)
+	for i := 0; i < 100; i++ {
+		clock.Advance(10 * time.Millisecond)
+		_, _ = r.Write(steady)
+	}
+
+	if got := r.Throughput(); !withinTolerance(got, 10000) {
+		t.Errorf("Throughput() got = %v, want ~10000", got)
+	}
+
+	// 10100 bytes written, 9900 left at 10KB/s
+	if got := r.ETA(20000); !withinTolerance(got.Seconds(), 0.99) {
+		t.Errorf("ETA() got = %v, want ~990ms", got)
+	}
+	if got := r.ETA(10000); got != 0 {
+		t.Errorf("ETA() of a total already written got = %v, want 0", got)
+	}
+
+	// idle time lowers the average
+	clock.Advance(time.Second)
+	if got := r.Throughput(); !withinTolerance(got, 5050) {
+		t.Errorf("Throughput() after idle got = %v, want ~5050", got)
+	}
+
+	if got := NewRingBuffer(0, 8).Throughput(); got != 0 {
+		t.Errorf("Throughput() without tracking got = %v, want 0", got)
+	}
+}
@@ -0,0 +1,96 @@
+package ringbuffer
+
+// RecordRing is a ring of fixed-size records, modeled on container/ring,
+// storing them in the underlying slice of a RingBuffer.
+// The ring holds Len() records of the record size set in NewRecordRing,
+// initially zeroed. The current record is the first one visited by Do, and
+// Move rotates the ring changing the current record.
+type RecordRing struct {
+	rb         *RingBuffer
+	recordSize int
+}
+
+// NewRecordRing creates a ring of n records of recordSize bytes each,
+// allocating all the memory needed at once.
+// A negative n or recordSize is considered 0.
+func NewRecordRing(n, recordSize int) *RecordRing {
+	if n < 0 {
+		n = 0
+	}
+	if recordSize < 0 {
+		recordSize = 0
+	}
+
+	size := n * recordSize
+	rb := NewRingBuffer(size, size)
+	_, _ = rb.Fill(0, size)
+
+	return &RecordRing{
+		rb:         rb,
+		recordSize: recordSize,
+	}
+}
+
+// Len returns the number of records in the ring.
+func (rr *RecordRing) Len() int {
+	if rr.recordSize == 0 {
+		return 0
+	}
+	return rr.rb.Len() / rr.recordSize
+}
+
+// RecordSize returns the size of each record in the ring.
+func (rr *RecordRing) RecordSize() int {
+	return rr.recordSize
+}
+
+// Value returns the current record. It shares memory with the ring, so it
+// can be modified in place.
+func (rr *RecordRing) Value() []byte {
+	if rr.Len() == 0 {
+		return nil
+	}
+	return rr.record(0)
+}
+
+// Do calls fn on each record of the ring, in order, starting from the
+// current one. The records share memory with the ring, so they can be
+// modified in place, but they must not be retained after the call.
+func (rr *RecordRing) Do(fn func(record []byte)) {
+	for i := 0; i < rr.Len(); i++ {
+		fn(rr.record(i))
+	}
+}
+
+// Move rotates the ring by n records, forward if n >= 0 or backward if
+// n < 0, making the record n positions away the current one.
+func (rr *RecordRing) Move(n int) {
+	records := rr.Len()
+	if records == 0 {
+		return
+	}
+
+	n %= records
+	if n < 0 {
+		n += records
+	}
+
+	// the ring is always full, so the logical start can be moved freely
+	// using the ring representation
+	rr.rb.pos = (rr.start() + n*rr.recordSize) % len(rr.rb.buf)
+	rr.rb.ringMode = true
+}
+
+// record returns the record at the logical index i.
+func (rr *RecordRing) record(i int) []byte {
+	start := (rr.start() + i*rr.recordSize) % len(rr.rb.buf)
+	return rr.rb.buf[start : start+rr.recordSize : start+rr.recordSize]
+}
+
+// start returns the index of the current record in the underlying slice.
+func (rr *RecordRing) start() int {
+	if rr.rb.ringMode {
+		return rr.rb.pos % len(rr.rb.buf)
+	}
+	return 0
+}
@@ -0,0 +1,69 @@
+package ringbuffer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRecordRing(t *testing.T) {
+	t.Parallel()
+
+	rr := NewRecordRing(4, 2)
+
+	if got := rr.Len(); got != 4 {
+		t.Errorf("Len() got = %d, want 4", got)
+	}
+	if got := rr.RecordSize(); got != 2 {
+		t.Errorf("RecordSize() got = %d, want 2", got)
+	}
+
+	// set the records to "a0", "b1", "c2", "d3"
+	for i := 0; i < rr.Len(); i++ {
+		copy(rr.Value(), []byte{'a' + byte(i), '0' + byte(i)})
+		rr.Move(1)
+	}
+
+	collect := func() []string {
+		var got []string
+		rr.Do(func(record []byte) {
+			got = append(got, string(record))
+		})
+		return got
+	}
+
+	tests := []struct {
+		move int
+		want []string
+	}{
+		{move: 0, want: []string{"a0", "b1", "c2", "d3"}},
+		{move: 1, want: []string{"b1", "c2", "d3", "a0"}},
+		{move: 2, want: []string{"d3", "a0", "b1", "c2"}},
+		{move: -1, want: []string{"c2", "d3", "a0", "b1"}},
+		{move: 6, want: []string{"a0", "b1", "c2", "d3"}},
+		{move: -9, want: []string{"d3", "a0", "b1", "c2"}},
+	}
+	for _, tt := range tests {
+		rr.Move(tt.move)
+
+		if got := collect(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Do() after Move(%d) got = %q, want %q", tt.move, got, tt.want)
+		}
+		if got := string(rr.Value()); got != tt.want[0] {
+			t.Errorf("Value() after Move(%d) got = %q, want %q", tt.move, got, tt.want[0])
+		}
+	}
+}
+
+func TestRecordRing_Empty(t *testing.T) {
+	t.Parallel()
+
+	for _, rr := range []*RecordRing{NewRecordRing(0, 4), NewRecordRing(4, 0), NewRecordRing(-1, -1)} {
+		rr.Move(3)
+		rr.Do(func(record []byte) {
+			t.Errorf("Do() called on an empty ring")
+		})
+		if got := rr.Value(); got != nil {
+			t.Errorf("Value() got = %v, want nil", got)
+		}
+	}
+}
@@ -3,24 +3,154 @@
 // reached, and, after that, like a
 // https://en.wikipedia.org/wiki/Circular_buffer always overwriting the oldest
 // content without using new memory.
-// The buffer implements the io.Writer, io.Closer and fmt.Stringer interfaces.
+// The buffer implements the io.Writer, io.Reader, io.Closer, io.ReaderFrom,
+// io.WriterTo, io.StringWriter, io.ByteWriter and fmt.Stringer interfaces.
 package ringbuffer
 
-import "errors"
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
 
 // expansionFactor is the growing factor of the underlying slice
 const expansionFactor = 2
 
+// interfaces implemented by RingBuffer
+var (
+	_ io.Writer       = (*RingBuffer)(nil)
+	_ io.Reader       = (*RingBuffer)(nil)
+	_ io.Closer       = (*RingBuffer)(nil)
+	_ io.ReaderFrom   = (*RingBuffer)(nil)
+	_ io.WriterTo     = (*RingBuffer)(nil)
+	_ io.StringWriter = (*RingBuffer)(nil)
+	_ io.ByteWriter   = (*RingBuffer)(nil)
+	_ fmt.Stringer    = (*RingBuffer)(nil)
+)
+
+// fillChunkSize is the size of the slice used by Fill.
+const fillChunkSize = 512
+
+// readChunkSize is the size of the temporary slice used to move data from a
+// reader into the buffer.
+const readChunkSize = 32 * 1024
+
 // RingBuffer is a variable-sized buffer of bytes with a maximum size.
 // After the limit is reached, it behaves like a ring buffer, overwriting
 // content if and retaining only the last `size` bytes.
-// The buffer implements the io.Writer and io.Closer interface.
+// The buffer implements the io.Writer, io.Reader, io.Closer, io.ReaderFrom,
+// io.WriterTo, io.StringWriter, io.ByteWriter and fmt.Stringer interfaces.
 type RingBuffer struct {
+	// The content is buf[off:pos], or buf[pos:] followed by buf[:pos] in
+	// ring mode. The ring mode is entered lazily, only when a write actually
+	// overwrites some content: a buffer filled exactly up to maxSize is not
+	// in ring mode yet. So, as long as the buffer is only written, ringMode
+	// is true if and only if written > maxSize; methods removing content,
+	// like Drain, leave the ring mode.
+	// off is the number of bytes consumed by Drain at the beginning of buf,
+	// always 0 in ring mode: they are moved away only when a write needs
+	// their room, so that consuming the content in small reads is linear.
 	buf      []byte
+	off      int
 	pos      int
 	written  int
 	ringMode bool
 	maxSize  int
+
+	// alloc replaces makeSlice when set, so tests can make allocations
+	// fail deterministically
+	alloc func(n int) ([]byte, error)
+
+	// growth replaces the default geometric growth, see WithGrowth
+	growth GrowthStrategy
+
+	overflowPolicy OverflowPolicy
+	maxWriteSize   int
+	frozen         bool
+	closed         bool
+	lazyFull       bool
+
+	// lifecycle generation, incremented by Reset and Close, see
+	// ReadWithToken
+	generation uint64
+
+	// consume watermark, see Consume
+	consumed   int64
+	consumeGap int64
+
+	// read cursor, the offset of the next byte to Read, see ReadGap
+	readNext int64
+	readGap  int64
+
+	// midLine is true when the oldest byte held doesn't start a line, since
+	// the byte discarded just before it is not '\n', see LastLines
+	midLine bool
+
+	// write rate tracking, see WithWriteRate
+	rateWindow time.Duration
+	rate       float64
+	rateLast   time.Time
+
+	// average throughput since the first write, see WithThroughput
+	throughput *throughputTracker
+
+	// write latency histogram, see WithLatencyTracking
+	latency *latencyHistogram
+
+	// write and content size histograms, see WithWriteSizeHistogram
+	sizes *sizeHistograms
+
+	// eviction counters, see WithDetailedStats
+	evictions *evictionStats
+
+	// destination of the overwritten content, see WithSpill
+	spill       io.Writer
+	spillPolicy SpillPolicy
+	spillErr    error
+
+	// deduplication of consecutive writes, see WithDedup
+	dedup     bool
+	lastChunk []byte
+	repeats   int
+
+	// String result caching, see WithStringCache
+	stringCache bool
+	cached      string
+	cachedValid bool
+
+	// source offsets of the content, see WithSourceOffsets
+	sourceOffsets bool
+	sources       []sourceSpan
+
+	// low-water mark callback, see OnDrainBelow
+	drainThreshold int
+	onDrainBelow   func()
+	aboveThreshold bool
+
+	// state transitions callback, see OnStateChange
+	onStateChange func(from, to State)
+	state         State
+
+	// caller attributes, see SetMeta
+	meta map[string]interface{}
+
+	// line callback, see OnLine
+	onLine      func(line []byte)
+	partialLine []byte
+
+	// line truncation, see WithMaxLineLength
+	maxLineLength int
+	lineMarker    []byte
+	lineLen       int
+
+	// now returns the current time, if nil time.Now is used
+	now func() time.Time
 }
 
 // Cap returns the actual size of memory allocated for the underlying buffer.
@@ -29,27 +159,613 @@ func (r *RingBuffer) Cap() int {
 	return cap(r.buf)
 }
 
+// Overhead returns the ratio between the memory allocated for the
+// underlying buffer and the content it holds, i.e. Cap()/Len(), e.g. to
+// plan the maximum sizes of many buffers: the higher it is, the more
+// allocated memory is unused.
+// It returns 0 if the buffer is empty.
+func (r *RingBuffer) Overhead() float64 {
+	if r.Len() == 0 {
+		return 0
+	}
+	return float64(r.Cap()) / float64(r.Len())
+}
+
 // Close removes any reference of the underlying slice letting the memory be
-// freed.
-// Any other method called on this RingBuffer has no meaning and could lead to
-// panic.
+// freed. The metadata set with SetMeta are dropped too.
+// The methods writing into the buffer return ErrClosed afterwards; any other
+// method called on this RingBuffer has no meaning and could lead to panic.
+// The spill destination set with WithSpill is flushed and closed, and its
+// error is returned; the buffer is closed anyway.
 func (r *RingBuffer) Close() error {
+	r.invalidateString()
+	r.closed = true
 	r.buf = nil
+	r.off = 0
 	r.pos = 0
 	r.ringMode = false
 	r.written = 0
 	r.maxSize = 0
+	r.midLine = false
+	r.meta = nil
+	r.generation++
+	r.checkDrainBelow()
+	r.checkState()
+	return r.closeSpill()
+}
+
+// checkWritable returns ErrClosed or ErrFrozen if the content of the buffer
+// can't be changed.
+func (r *RingBuffer) checkWritable() error {
+	if r.closed {
+		return ErrClosed
+	}
+	if r.frozen {
+		return ErrFrozen
+	}
 	return nil
 }
 
+// checkWriteSize returns ErrRecordTooLarge or ErrWriteTooLarge if a single
+// write of n bytes is rejected because of its size.
+func (r *RingBuffer) checkWriteSize(n int) error {
+	if r.overflowPolicy == OverflowReject && n > r.maxSize {
+		return fmt.Errorf("%w: %d bytes, maximum size %d", ErrRecordTooLarge, n, r.maxSize)
+	}
+	if r.maxWriteSize > 0 && n > r.maxWriteSize {
+		return fmt.Errorf("%w: %d bytes, maximum write size %d", ErrWriteTooLarge, n, r.maxWriteSize)
+	}
+	return nil
+}
+
+// writeLimit returns the size of the largest single write accepted by
+// checkWriteSize, or maxInt if there is no limit.
+func (r *RingBuffer) writeLimit() int {
+	limit := maxInt
+	if r.overflowPolicy == OverflowReject {
+		limit = r.maxSize
+	}
+	if r.maxWriteSize > 0 && r.maxWriteSize < limit {
+		limit = r.maxWriteSize
+	}
+	return limit
+}
+
 // Write appends the contents of p to the buffer, growing the buffer as
-// needed. The return value n is the length of p; err is always nil. If the
-// buffer becomes too large, Write will panic with ErrTooLarge.
+// needed. The return value n is the length of p. If the buffer can't be
+// grown, Write returns an error wrapping ErrTooLarge.
+// Under the OverflowReject policy, a write larger than the maximum size
+// returns ErrRecordTooLarge without writing anything, and so does a write
+// larger than the limit set with WithMaxWriteSize, with ErrWriteTooLarge.
+// With WithDedup, a write identical to the previous one is not stored.
+// If the buffer is frozen, Write returns ErrFrozen, and if it is closed,
+// ErrClosed.
 func (r *RingBuffer) Write(p []byte) (int, error) {
+	if err := r.checkWritable(); err != nil {
+		return 0, err
+	}
+
+	if err := r.checkWriteSize(len(p)); err != nil {
+		return 0, err
+	}
+
+	if r.sizes != nil {
+		r.sizes.writes.record(len(p))
+	}
+
+	if r.dedup && r.isRepeat(p) {
+		r.repeats++
+		return len(p), nil
+	}
+
+	r.invalidateString()
+
+	// the bytes dropped by the line truncation are still reported as
+	// written, like the skipped repeats
+	stored := p
+	if r.maxLineLength > 0 {
+		stored = r.truncateLines(p)
+	}
+
+	if r.spill != nil {
+		if err := r.spillEvicted(stored); err != nil {
+			return 0, err
+		}
+	}
+
+	var (
+		n         int
+		err       error
+		start     time.Time
+		lenBefore = r.Len()
+		midLine   = r.midLineAfter(lenBefore+len(stored)-r.maxSize, stored)
+	)
+	if r.latency != nil {
+		start = r.clock()
+	}
+	if r.ringMode {
+		n, err = r.writeRing(stored)
+	} else {
+		n, err = r.write(stored)
+	}
+	if r.latency != nil {
+		r.latency.record(r.clock().Sub(start))
+	}
+	if err != nil {
+		r.checkDrainBelow()
+		r.checkState()
+		return n, err
+	}
+	r.midLine = midLine
+
+	if r.dedup {
+		r.recordChunk(p)
+	}
+	r.trackWrite(n, lenBefore)
+	if r.onLine != nil {
+		r.emitLines(stored)
+	}
+	r.checkDrainBelow()
+	r.checkState()
+	return len(p), nil
+}
+
+// newlinesPool recycles the indexes of the newlines WriteWith overwrites in
+// place.
+var newlinesPool = sync.Pool{
+	New: func() interface{} {
+		return new([]int)
+	},
+}
+
+// appendNewlines appends to idx the indexes of the '\n' bytes of p.
+func appendNewlines(idx []int, p []byte) []int {
+	for i := 0; ; {
+		j := bytes.IndexByte(p[i:], '\n')
+		if j < 0 {
+			return idx
+		}
+		idx = append(idx, i+j)
+		i += j + 1
+	}
+}
+
+// WriteWith reserves n bytes of space in the buffer and calls fn to fill
+// them in place, avoiding an intermediate slice when generating content.
+// fn receives a slice of n bytes and returns how many of them it has
+// written, which are then committed as written data (values outside [0, n]
+// are clamped).
+// When the n bytes are not contiguous in the underlying buffer, because they
+// span the end of the ring, or when the write can't be done in place (e.g.
+// with WithDedup, WithDetailedStats or WithWriteSizeHistogram, which look at
+// every write as a whole), fn receives a temporary slice which is then
+// written with Write.
+// In ring mode the reserved space holds the oldest content, so fn must not
+// modify the bytes of dst beyond the ones it reports as written.
+// It returns the number of bytes written, and ErrFrozen if the buffer is
+// frozen.
+func (r *RingBuffer) WriteWith(n int, fn func(dst []byte) int) (int, error) {
+	if err := r.checkWritable(); err != nil {
+		return 0, err
+	}
+	if n <= 0 {
+		return 0, nil
+	}
+
+	if r.pos+n > len(r.buf) {
+		r.compact()
+	}
+
+	var dst []byte
+	switch {
+	case r.dedup || r.maxLineLength > 0 || r.evictions != nil || r.spill != nil || r.sizes != nil || n > r.maxSize || r.maxWriteSize > 0 && n > r.maxWriteSize:
+		// not in place
+	case !r.ringMode && r.pos+n <= r.maxSize:
+		if len(r.buf) < r.pos+n {
+			size := r.pos + n
+			if r.lazyFull {
+				size = r.maxSize
+			}
+			if err := r.Grow(size); err != nil {
+				return 0, err
+			}
+		}
+		dst = r.buf[r.pos : r.pos+n]
+	case r.ringMode && r.pos+n <= len(r.buf):
+		dst = r.buf[r.pos : r.pos+n]
+	}
+
+	if dst == nil {
+		tmp := make([]byte, n)
+		return r.Write(tmp[:clamp(fn(tmp), 0, n)])
+	}
+
+	r.invalidateString()
+
+	// in ring mode fn overwrites the oldest bytes, and the last one it
+	// evicts tells whether the content starts a line: since it is known only
+	// once fn returns, the newlines are located before
+	var newlines *[]int
 	if r.ringMode {
-		return r.writeRing(p)
+		newlines = newlinesPool.Get().(*[]int)
+		*newlines = appendNewlines((*newlines)[:0], dst)
+	}
+
+	var (
+		start     time.Time
+		lenBefore = r.Len()
+	)
+	if r.latency != nil {
+		start = r.clock()
+	}
+	m := clamp(fn(dst), 0, n)
+	if r.latency != nil {
+		r.latency.record(r.clock().Sub(start))
+	}
+
+	if newlines != nil {
+		if m > 0 {
+			i := sort.SearchInts(*newlines, m-1)
+			r.midLine = i == len(*newlines) || (*newlines)[i] != m-1
+		}
+		newlinesPool.Put(newlines)
+	}
+	r.pos += m
+	r.written += m
+
+	r.trackWrite(m, lenBefore)
+	if r.onLine != nil {
+		r.emitLines(dst[:m])
+	}
+	r.checkDrainBelow()
+	r.checkState()
+	return m, nil
+}
+
+// clamp returns v limited to the range [lo, hi].
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// trackWrite updates the statistics enabled by the options after a write
+// of n bytes into a buffer holding lenBefore bytes.
+func (r *RingBuffer) trackWrite(n, lenBefore int) {
+	if r.rateWindow > 0 {
+		r.trackRate(n)
+	}
+	if r.throughput != nil {
+		r.trackThroughput(n)
+	}
+	if r.evictions != nil {
+		r.evictions.track(lenBefore + n - r.Len())
+	}
+	if r.sizes != nil {
+		r.sizes.lengths.record(r.Len())
+	}
+}
+
+// WriteString writes the content of s into the buffer, like Write, copying
+// it straight from the string, without converting it to a slice.
+// To amortize the cost of many small writes, use a Builder.
+func (r *RingBuffer) WriteString(s string) (int, error) {
+	// the options looking at the written bytes need them as a slice, and
+	// so does a string longer than the maximum size, to be cut
+	if r.dedup || r.maxLineLength > 0 || r.spill != nil || r.onLine != nil || len(s) == 0 || len(s) > r.maxSize {
+		return r.Write([]byte(s))
+	}
+
+	if err := r.checkWritable(); err != nil {
+		return 0, err
+	}
+	if err := r.checkWriteSize(len(s)); err != nil {
+		return 0, err
+	}
+	if r.sizes != nil {
+		r.sizes.writes.record(len(s))
+	}
+
+	r.invalidateString()
+
+	var (
+		start     time.Time
+		lenBefore = r.Len()
+		midLine   = r.midLineAfter(lenBefore + len(s) - r.maxSize)
+	)
+	if r.latency != nil {
+		start = r.clock()
+	}
+	first, second, err := r.space(len(s))
+	if err == nil {
+		copy(second, s[copy(first, s):])
+	}
+	if r.latency != nil {
+		r.latency.record(r.clock().Sub(start))
+	}
+	if err != nil {
+		r.checkDrainBelow()
+		r.checkState()
+		return 0, err
+	}
+	r.midLine = midLine
+
+	r.trackWrite(len(s), lenBefore)
+	r.checkDrainBelow()
+	r.checkState()
+	return len(s), nil
+}
+
+// space makes room for n new bytes, with 0 < n <= maxSize, following the
+// same rules of write and writeRing, and returns the one or two slices of
+// the underlying buffer where they have to be copied.
+func (r *RingBuffer) space(n int) (first, second []byte, err error) {
+	if !r.ringMode {
+		if r.pos+n > len(r.buf) {
+			r.compact()
+		}
+		if r.pos+n > len(r.buf) && len(r.buf) < r.maxSize {
+			size := r.pos + n
+			if r.lazyFull {
+				size = r.maxSize
+			}
+			if err := r.Grow(size); err != nil {
+				return nil, nil, err
+			}
+		}
+		if r.pos+n <= len(r.buf) {
+			first = r.buf[r.pos : r.pos+n]
+			r.pos += n
+			r.written += n
+			return first, nil, nil
+		}
+		r.ringMode = true
+	}
+
+	k := len(r.buf) - r.pos
+	if k > n {
+		k = n
+	}
+	first = r.buf[r.pos : r.pos+k]
+	r.pos += k
+	if k < n {
+		second = r.buf[:n-k]
+		r.pos = n - k
+	}
+	r.written += n
+	return first, second, nil
+}
+
+// WriteStringChecked writes s like WriteString, and also returns how many
+// bytes of the previous content have been overwritten to make room for it,
+// so the caller can tell a write that caused data loss from a plain one.
+func (r *RingBuffer) WriteStringChecked(s string) (n, evicted int, err error) {
+	length, written := r.Len(), r.written
+
+	n, err = r.WriteString(s)
+
+	// the bytes no longer held, but only the ones written before s count
+	dropped := length + r.written - written - r.Len()
+	if dropped > length {
+		dropped = length
+	}
+	return n, dropped, err
+}
+
+// WriteTracked writes p like Write, and returns the range [start, end) the
+// written bytes occupy in the stream of all the bytes written so far, i.e.
+// Written() before and after the write. The range is stable even when the
+// bytes are later overwritten, so it can be stored in an external index and
+// used with OffsetBytes.
+// A write skipped by WithDedup or failed occupies an empty range.
+func (r *RingBuffer) WriteTracked(p []byte) (start, end int64, err error) {
+	start = int64(r.written)
+	_, err = r.Write(p)
+	return start, int64(r.written), err
+}
+
+// WriteByte writes the byte c into the buffer, like Write.
+func (r *RingBuffer) WriteByte(c byte) error {
+	_, err := r.Write([]byte{c})
+	return err
+}
+
+// WriteN writes p like Write, except under the OverflowReject policy, where
+// it never overwrites content: only the bytes fitting in the free space,
+// up to the maximum size, are written, and io.ErrShortWrite is returned
+// with their number if they are less than len(p), like the io.Writer of a
+// bounded sink.
+func (r *RingBuffer) WriteN(p []byte) (int, error) {
+	if r.overflowPolicy != OverflowReject {
+		return r.Write(p)
+	}
+
+	free := r.maxSize - r.Len()
+	if len(p) <= free {
+		return r.Write(p)
+	}
+
+	n, err := r.Write(p[:free])
+	if err != nil {
+		return n, err
+	}
+	return n, io.ErrShortWrite
+}
+
+// WriteExact writes p like Write, as a guard for framing protocols expecting
+// records of an exact size: if len(p) is not expectLen, nothing is written
+// and io.ErrShortBuffer is returned.
+func (r *RingBuffer) WriteExact(p []byte, expectLen int) error {
+	if len(p) != expectLen {
+		return io.ErrShortBuffer
+	}
+	_, err := r.Write(p)
+	return err
+}
+
+// WriteFmt formats according to a format specifier, like fmt.Sprintf, and
+// writes the result into the buffer with a single Write.
+// The formatting uses the pooled buffers of the fmt package, so no
+// intermediate string is allocated.
+// It returns the number of bytes written and any write error encountered.
+func (r *RingBuffer) WriteFmt(format string, args ...interface{}) (int, error) {
+	return fmt.Fprintf(r, format, args...)
+}
+
+// WriteMulti writes all the slices in ps, in order, with the same result of
+// a sequence of Write calls, and returns the total number of bytes written.
+// Since only the last maxSize bytes of the batch can be retained, the slices,
+// or parts of them, that would be overwritten by the following ones in the
+// same batch are skipped without copying them.
+// With WithDedup, WithMaxWriteSize, WithMaxLineLength, WithSpill,
+// WithDetailedStats, WithWriteSizeHistogram, the OverflowReject policy or an
+// OnLine callback, each slice has to be processed on its own, so they are
+// just written one by one.
+func (r *RingBuffer) WriteMulti(ps ...[]byte) (int, error) {
+	if err := r.checkWritable(); err != nil {
+		return 0, err
+	}
+
+	if r.dedup || r.overflowPolicy != OverflowTruncate || r.onLine != nil || r.maxWriteSize > 0 || r.maxLineLength > 0 || r.evictions != nil || r.spill != nil || r.sizes != nil {
+		total := 0
+		for _, p := range ps {
+			n, err := r.Write(p)
+			total += n
+			if err != nil {
+				return total, err
+			}
+		}
+		return total, nil
+	}
+
+	kept := keepLast(ps, r.maxSize)
+
+	// the skipped bytes are accounted as if they had been written
+	skipped := sumLen(ps) - sumLen(kept)
+	midLine := r.midLineAfter(r.Len()+skipped, ps...)
+	if skipped > 0 {
+		r.written += skipped
+		if r.rateWindow > 0 {
+			r.trackRate(skipped)
+		}
+		if r.throughput != nil {
+			r.trackThroughput(skipped)
+		}
+	}
+
+	n := skipped
+	for _, p := range kept {
+		m, err := r.Write(p)
+		n += m
+		if err != nil {
+			return n, err
+		}
+	}
+
+	// the skipped bytes have been overwritten as well, even if the kept
+	// ones have just filled an empty buffer
+	if skipped > 0 {
+		r.midLine = midLine
+		if !r.ringMode && r.pos == r.maxSize {
+			r.ringMode = true
+			r.pos = 0
+			r.checkState()
+		}
+	}
+	return n, nil
+}
+
+// AppendSegments writes the content of src into the buffer, with the same
+// result of Write(src.Bytes()), but copying the two parts of the content of
+// src straight into the buffer, without allocating an intermediate slice.
+// It returns the number of bytes written.
+func (r *RingBuffer) AppendSegments(src *RingBuffer) (int, error) {
+	// the options checking every single write need the content as a whole,
+	// and writing a buffer into itself would read the bytes being replaced
+	if src == r || r.dedup || r.overflowPolicy != OverflowTruncate || r.maxWriteSize > 0 || r.maxLineLength > 0 {
+		return r.Write(src.Bytes())
+	}
+
+	first, second := src.Segments()
+	return r.WriteMulti(first, second)
+}
+
+// CanMergeInto reports whether the content of r can be appended to other,
+// e.g. with other.AppendSegments(r), without losing any byte of the two
+// buffers. When it can't, reason explains why, e.g. to resize other first.
+func (r *RingBuffer) CanMergeInto(other *RingBuffer) (ok bool, reason string) {
+	if err := other.checkWritable(); err != nil {
+		return false, fmt.Sprintf("the destination can't be written: %v", err)
+	}
+
+	length, otherLength := r.Len(), other.Len()
+	if length > other.maxSize {
+		return false, fmt.Sprintf("%d bytes don't fit in the maximum size %d", length, other.maxSize)
+	}
+	if lost := otherLength + length - other.maxSize; lost > 0 {
+		return false, fmt.Sprintf("%d bytes of the destination would be overwritten: %d + %d bytes exceed the maximum size %d",
+			lost, otherLength, length, other.maxSize)
+	}
+	return true, ""
+}
+
+// keepLast returns the slices holding the last n bytes of ps, the first one
+// of them trimmed if needed, sharing memory with ps.
+func keepLast(ps [][]byte, n int) [][]byte {
+	for i := len(ps) - 1; i >= 0; i-- {
+		if len(ps[i]) >= n {
+			kept := make([][]byte, 0, len(ps)-i)
+			kept = append(kept, ps[i][len(ps[i])-n:])
+			return append(kept, ps[i+1:]...)
+		}
+		n -= len(ps[i])
+	}
+	return ps
+}
+
+// sumLen returns the total length of the slices in ps.
+func sumLen(ps [][]byte) int {
+	total := 0
+	for _, p := range ps {
+		total += len(p)
+	}
+	return total
+}
+
+// Fill writes n copies of the byte c into the buffer, going through Write in
+// chunks, so the buffer grows and wraps like with any other write, without
+// allocating a slice of n bytes.
+// It is useful to bring a buffer to a known state in tests and benchmarks.
+// It returns the number of bytes written and the first error encountered.
+// A n <= 0 writes nothing.
+func (r *RingBuffer) Fill(c byte, n int) (int, error) {
+	if n <= 0 {
+		return 0, nil
+	}
+
+	size := fillChunkSize
+	if n < size {
+		size = n
+	}
+	chunk := bytes.Repeat([]byte{c}, size)
+
+	written := 0
+	for written < n {
+		if left := n - written; left < len(chunk) {
+			chunk = chunk[:left]
+		}
+
+		m, err := r.Write(chunk)
+		written += m
+		if err != nil {
+			return written, err
+		}
 	}
-	return r.write(p)
+	return written, nil
 }
 
 // write copies the input slice p into the internal buffer.
@@ -57,12 +773,25 @@ func (r *RingBuffer) Write(p []byte) (int, error) {
 // If the buffer is smaller than required, it tries to expand it enough to
 // contains the input, using the expansionFactor.
 // If the maximumSize is reached, it acts like a ring buffer and calls writeRing.
+// Filling the buffer exactly up to maximumSize doesn't switch to ring mode:
+// that only happens when some content is actually overwritten, so the
+// content stays contiguous as long as possible.
 func (r *RingBuffer) write(p []byte) (int, error) {
 	pLen := len(p)
 
+	// the room of the drained bytes is used before growing or wrapping
+	if r.pos+pLen > len(r.buf) {
+		r.compact()
+	}
+
 	// if necessary, expands r.buf at least size || max
-	if len(r.buf) < r.pos+pLen {
-		err := r.Grow(r.pos + pLen)
+	if len(r.buf) < r.pos+pLen && len(r.buf) < r.maxSize {
+		size := r.pos + pLen
+		if r.lazyFull {
+			size = r.maxSize
+		}
+
+		err := r.Grow(size)
 		if err != nil {
 			return 0, err
 		}
@@ -73,10 +802,6 @@ func (r *RingBuffer) write(p []byte) (int, error) {
 		n := copy(r.buf[r.pos:], p)
 		r.written += n
 		r.pos += n
-		if r.pos == r.maxSize {
-			r.ringMode = true
-			r.pos = 0
-		}
 		return n, nil
 	}
 
@@ -116,97 +841,1116 @@ func (r *RingBuffer) writeRing(p []byte) (int, error) {
 	return pLen, nil
 }
 
-// Grow expands the underlying buffer, in order to be able to contain at least
-// size byte.
-// If size is greater than the limit defined via constructor, the latter is
-// used.
-// There is no need to call this method directly. It could be useful, however,
-// for performance reasons. If the caller at some point knows the expected
-// size, they could pre-expand the buffer in order to avoid multiple expensive
-// grow-and-copy on every write.
-func (r *RingBuffer) Grow(size int) error {
-	newSize := len(r.buf)
-
-	// multiply the buffer size by `expansionFactor`, until it is enough to
-	// contain `size`.
-	// Special case is buf size = 0, because it can't be multiplied.
-	if newSize == 0 {
-		newSize = 1
-	}
-	for newSize < size {
-		newSize *= expansionFactor
+// Read reads the oldest len(p) bytes from the buffer, or until the buffer is
+// empty, consuming them. The return value n is the number of bytes read.
+// If the buffer has no data to return, err is io.EOF (unless len(p) is zero).
+// If some bytes have been overwritten before being read, Read resumes from
+// the oldest retained byte and the number of skipped bytes is reported by
+// ReadGap.
+func (r *RingBuffer) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		r.readGap = 0
+		return 0, nil
 	}
 
-	// in any case a size bigger than defined cap is not allowed
-	if newSize >= r.maxSize {
-		newSize = r.maxSize
-	}
+	r.readGap = r.unreadLost()
+	r.readNext += r.readGap
 
-	// create a new bigger slice and copy all the content from the old buffer
-	// to the new
-	newBuf, err := makeSlice(newSize)
-	if err != nil {
-		// grow can fail
-		return err
+	if r.Len() == 0 {
+		return 0, io.EOF
 	}
-	copy(newBuf, r.buf)
-
-	// new buffer is the new slice
-	// the old one is no more referenced, so it could be collected.
-	r.buf = newBuf
 
-	return nil
+	n := r.readAt(p, 0)
+	r.Drain(n)
+	return n, nil
 }
 
-// makeSlice allocates a slice of size n.
-// If the allocation panics, this function recovers it and returns an error.
-func makeSlice(n int) (b []byte, err error) {
-	// If the make fails, give a known error.
-	defer func() {
-		if recover() != nil {
-			err = errors.New("can't make slice")
-		}
-	}()
-	b = make([]byte, n)
-	return
+// ReadGap returns the number of bytes overwritten before the last call to
+// Read could return them, or 0 if nothing has been skipped.
+func (r *RingBuffer) ReadGap() int64 {
+	return r.readGap
 }
 
-// Bytes returns a copy of the buffer content in a slice of bytes.
-func (r *RingBuffer) Bytes() []byte {
-	if r.ringMode {
-		out := make([]byte, r.maxSize)
-		copy(out, r.buf[r.pos:])
-		copy(out[r.maxSize-r.pos:], r.buf[:r.pos])
-		return out
+// unreadLost returns the number of bytes evicted after the read cursor,
+// i.e. written but never read nor drained.
+// If the counters have been reset in the meantime, the read cursor is moved
+// to the oldest retained byte and nothing is considered lost.
+func (r *RingBuffer) unreadLost() int64 {
+	oldest := r.OldestOffset()
+	if r.readNext > int64(r.written) {
+		r.readNext = oldest
+	}
+	if r.readNext < oldest {
+		return oldest - r.readNext
 	}
+	return 0
+}
 
-	out := make([]byte, r.pos)
-	copy(out, r.buf[:r.pos])
-	return out
+// skipDiscarded moves the read cursor past the content discarded on purpose,
+// e.g. by Drain, ClearContent, ReplaceRange or SetMaxSize, so that the next
+// Read doesn't report those bytes as lost, while it still reports the lost
+// ones counted by unreadLost before discarding them.
+func (r *RingBuffer) skipDiscarded(lost int64) {
+	r.readNext = r.OldestOffset() - lost
 }
 
-// String returns the buffer content as a string.
-// With this method RingBuffer implements the fmt.Stringer interface.
-func (r *RingBuffer) String() string {
-	if r.ringMode {
-		return string(r.buf[r.pos:]) + string(r.buf[:r.pos])
+// ReadFrom reads data from src until EOF and writes it into the buffer,
+// following the same rules as Write.
+// The return value is the number of bytes read. Any error except io.EOF
+// encountered during the read is also returned.
+func (r *RingBuffer) ReadFrom(src io.Reader) (int64, error) {
+	if err := r.checkWritable(); err != nil {
+		return 0, err
 	}
 
-	return string(r.buf[:r.pos])
-}
+	chunk := make([]byte, readChunkSize)
 
-// Written returns the number of bytes written so far in the buffer.
-func (r *RingBuffer) Written() int {
+	var read int64
+	for {
+		m, err := src.Read(chunk)
+		if m > 0 {
+			if _, wErr := r.Write(chunk[:m]); wErr != nil {
+				return read, wErr
+			}
+			read += int64(m)
+		}
+
+		if err == io.EOF {
+			return read, nil
+		}
+		if err != nil {
+			return read, err
+		}
+	}
+}
+
+// ReadFromConn reads data from conn until EOF and writes it into the buffer,
+// like ReadFrom, setting a read deadline of timeout before every read, so
+// that a stalled connection can't block it forever.
+// The return value is the number of bytes read, which are kept in the buffer
+// whatever the outcome. Any error except io.EOF is also returned; when the
+// deadline expires, it is a net.Error whose Timeout method returns true.
+// The read deadline of conn is cleared before returning.
+// A timeout <= 0 sets no deadline.
+func (r *RingBuffer) ReadFromConn(conn net.Conn, timeout time.Duration) (int64, error) {
+	if timeout <= 0 {
+		return r.ReadFrom(conn)
+	}
+
+	defer func() { _ = conn.SetReadDeadline(time.Time{}) }()
+	return r.ReadFrom(deadlineReader{conn: conn, timeout: timeout})
+}
+
+// deadlineReader reads from a connection with a fresh deadline on every
+// read.
+type deadlineReader struct {
+	conn    net.Conn
+	timeout time.Duration
+}
+
+func (d deadlineReader) Read(p []byte) (int, error) {
+	if err := d.conn.SetReadDeadline(time.Now().Add(d.timeout)); err != nil {
+		return 0, err
+	}
+	return d.conn.Read(p)
+}
+
+// WriteTo writes the buffer content to w until the buffer is empty or an
+// error occurs, consuming what has been written, like bytes.Buffer does.
+// The return value is the number of bytes written. Any error encountered
+// during the write is also returned.
+// Use CopyTo to write the content without consuming it.
+func (r *RingBuffer) WriteTo(w io.Writer) (int64, error) {
+	written, err := r.CopyTo(w)
+	r.Drain(int(written))
+	return written, err
+}
+
+// WriteToConsume is the same as WriteTo, with a name making clear that the
+// content written is consumed.
+func (r *RingBuffer) WriteToConsume(w io.Writer) (int64, error) {
+	return r.WriteTo(w)
+}
+
+// CopyTo writes the buffer content to w, like WriteTo, but leaves the
+// buffer unchanged.
+func (r *RingBuffer) CopyTo(w io.Writer) (int64, error) {
+	var written int64
+
+	first, second := r.Segments()
+	for _, segment := range [][]byte{first, second} {
+		if len(segment) == 0 {
+			continue
+		}
+
+		m, err := w.Write(segment)
+		written += int64(m)
+		if err == nil && m < len(segment) {
+			err = io.ErrShortWrite
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+
+	return written, nil
+}
+
+// ReadFromAt reads n bytes from src starting at offset off and writes them
+// into the buffer, following the same rules as Write.
+// It doesn't change any seek offset of the source, so it can be used to load
+// arbitrary regions of a file. With WithSourceOffsets, the offsets in src of
+// the bytes written are tracked, see SourceOffset.
+// The return value is the number of bytes read. Any error returned by src is
+// returned as well, io.EOF included if the source ends before n bytes.
+func (r *RingBuffer) ReadFromAt(src io.ReaderAt, off, n int64) (int64, error) {
+	if err := r.checkWritable(); err != nil {
+		return 0, err
+	}
+	if n <= 0 {
+		return 0, nil
+	}
+
+	size := int64(readChunkSize)
+	if n < size {
+		size = n
+	}
+	chunk := make([]byte, size)
+
+	var read int64
+	for read < n {
+		if left := n - read; left < int64(len(chunk)) {
+			chunk = chunk[:left]
+		}
+
+		m, err := src.ReadAt(chunk, off+read)
+		if m > 0 {
+			start := r.written
+			if _, wErr := r.Write(chunk[:m]); wErr != nil {
+				return read, wErr
+			}
+			if r.sourceOffsets && r.written-start == m {
+				r.trackSource(int64(start), off+read, int64(m))
+			}
+			read += int64(m)
+		}
+
+		if err != nil {
+			// a ReaderAt is allowed to return io.EOF together with the last
+			// requested bytes
+			if err == io.EOF && read == n {
+				break
+			}
+			return read, err
+		}
+	}
+
+	return read, nil
+}
+
+// Grow expands the underlying buffer, in order to be able to contain at least
+// size byte.
+// If size is greater than the limit defined via constructor, the latter is
+// used.
+// There is no need to call this method directly. It could be useful, however,
+// for performance reasons. If the caller at some point knows the expected
+// size, they could pre-expand the buffer in order to avoid multiple expensive
+// grow-and-copy on every write.
+// A size lower than or equal to 0 does nothing.
+// When the capacity of the underlying slice is already enough, the slice is
+// extended in place, without allocating and copying.
+func (r *RingBuffer) Grow(size int) error {
+	if size <= 0 {
+		return nil
+	}
+
+	newSize := len(r.buf)
+
+	// multiply the buffer size by `expansionFactor`, or follow the growth
+	// strategy, until it is enough to contain `size`.
+	// Special case is buf size = 0, because it can't be multiplied.
+	if newSize == 0 {
+		newSize = 1
+	}
+	for newSize < size {
+		newSize = r.nextSize(newSize, size)
+	}
+
+	// in any case a size bigger than defined cap is not allowed
+	if newSize >= r.maxSize {
+		newSize = r.maxSize
+	}
+
+	// the backing array could already have room for it, e.g. when it has
+	// been adopted from a pool, so it is enough to extend the slice
+	if newSize >= len(r.buf) && newSize <= cap(r.buf) {
+		r.buf = r.buf[:newSize]
+		return nil
+	}
+
+	// create a new bigger slice and copy all the content from the old buffer
+	// to the new
+	newBuf, err := r.allocate(newSize)
+	if err != nil {
+		// grow can fail
+		return err
+	}
+	copy(newBuf, r.buf)
+
+	// new buffer is the new slice
+	// the old one is no more referenced, so it could be collected.
+	r.buf = newBuf
+
+	return nil
+}
+
+// allocate allocates a slice of size n for the underlying buffer, with the
+// alloc hook if set, or makeSlice.
+func (r *RingBuffer) allocate(n int) ([]byte, error) {
+	if r.alloc != nil {
+		return r.alloc(n)
+	}
+	return makeSlice(n)
+}
+
+// makeSlice allocates a slice of size n.
+// If the allocation panics, this function recovers it and returns an error
+// wrapping ErrTooLarge, with the requested size and the panic value.
+func makeSlice(n int) (b []byte, err error) {
+	// If the make fails, give a known error.
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("%w: allocation of %d bytes failed: %v", ErrTooLarge, n, rec)
+		}
+	}()
+	b = make([]byte, n)
+	return
+}
+
+// ReadWithToken returns a copy of the buffer content, like Bytes, together
+// with the generation of the buffer, a token incremented every time the
+// buffer is cleared by Reset, ResetFull or Close. Comparing the tokens of
+// two reads tells whether the buffer has been cleared in between, e.g. to
+// tell a buffer emptied by Read from a reset one.
+// Writes, reads and drains don't change the token.
+func (r *RingBuffer) ReadWithToken() (content []byte, token uint64) {
+	return r.Bytes(), r.generation
+}
+
+// Bytes returns a copy of the buffer content in a slice of bytes.
+func (r *RingBuffer) Bytes() []byte {
+	out := make([]byte, r.Len())
+	r.readAt(out, 0)
+	return out
+}
+
+// BytesNL returns a copy of the buffer content terminated by '\n', e.g. for
+// payloads that must be newline-terminated: a '\n' is appended only if the
+// content doesn't already end with one. An empty content is returned as
+// is. The buffer is not modified.
+func (r *RingBuffer) BytesNL() []byte {
+	length := r.Len()
+	if length == 0 {
+		return []byte{}
+	}
+
+	out := make([]byte, length, length+1)
+	r.readAt(out, 0)
+	if out[length-1] != '\n' {
+		out = append(out, '\n')
+	}
+	return out
+}
+
+// SectionReader returns an io.SectionReader over n bytes of the content
+// starting at the logical index off, where 0 is the oldest byte retained.
+// The section is backed by a copy of those bytes taken at call time, so it
+// is not affected by the following writes.
+// The range is limited to the current content: an off beyond it, or a
+// negative one, gives an empty section.
+func (r *RingBuffer) SectionReader(off, n int64) *io.SectionReader {
+	length := int64(r.Len())
+	if off < 0 || off > length {
+		off = length
+	}
+	if n < 0 {
+		n = 0
+	}
+	if n > length-off {
+		n = length - off
+	}
+
+	snapshot := make([]byte, n)
+	r.readAt(snapshot, int(off))
+	return io.NewSectionReader(bytes.NewReader(snapshot), 0, n)
+}
+
+// Segments returns the buffer content as two slices of the underlying buffer,
+// in order, without copying. The second one is empty when the content is
+// contiguous.
+// The slices share memory with the buffer: they must not be modified, and
+// they are valid only until the next call to a method changing the buffer.
+func (r *RingBuffer) Segments() (first, second []byte) {
+	if r.ringMode {
+		return r.buf[r.pos:], r.buf[:r.pos]
+	}
+	return r.buf[r.off:r.pos], nil
+}
+
+// StartIndex returns the index, in the slice returned by Buffer, where the
+// content begins.
+func (r *RingBuffer) StartIndex() int {
+	if r.ringMode {
+		return r.pos
+	}
+	return r.off
+}
+
+// EndIndex returns the index, in the slice returned by Buffer, just after
+// the end of the content. When the content wraps around, it is lower than
+// or equal to StartIndex, and the content is Buffer()[StartIndex():]
+// followed by Buffer()[:EndIndex()].
+func (r *RingBuffer) EndIndex() int {
+	return r.pos
+}
+
+// Buffer returns the whole underlying slice, without copying, for callers
+// building their own views of the content with StartIndex and EndIndex.
+// It is dangerous: the bytes outside the content are garbage, the slice
+// must not be modified, and it is valid only until the next call to a
+// method changing the buffer, which may reallocate or overwrite it.
+// Prefer Segments when possible.
+func (r *RingBuffer) Buffer() []byte {
+	return r.buf
+}
+
+// WritevBuffers returns the buffer content as net.Buffers, holding the
+// non-empty slices returned by Segments, in order.
+// The content can then be sent to a connection with net.Buffers.WriteTo,
+// using a single writev system call where supported.
+// The same contract of Segments applies: the slices must not be modified,
+// and they are valid only until the next call to a method changing the
+// buffer.
+func (r *RingBuffer) WritevBuffers() net.Buffers {
+	first, second := r.Segments()
+
+	bufs := make(net.Buffers, 0, 2)
+	if len(first) > 0 {
+		bufs = append(bufs, first)
+	}
+	if len(second) > 0 {
+		bufs = append(bufs, second)
+	}
+	return bufs
+}
+
+// String returns the buffer content as a string.
+// With this method RingBuffer implements the fmt.Stringer interface.
+// With WithStringCache, the result is computed again only if the content has
+// changed since the previous call.
+func (r *RingBuffer) String() string {
+	if !r.stringCache {
+		return r.string()
+	}
+
+	if !r.cachedValid {
+		r.cached = r.string()
+		r.cachedValid = true
+	}
+	return r.cached
+}
+
+// string returns the buffer content as a string.
+func (r *RingBuffer) string() string {
+	if r.ringMode {
+		return string(r.buf[r.pos:]) + string(r.buf[:r.pos])
+	}
+
+	return string(r.buf[r.off:r.pos])
+}
+
+// SafeString returns the buffer content as a string that is always valid
+// UTF-8, e.g. to display it: every run of invalid bytes, like binary data or
+// a multi-byte character whose beginning has been overwritten, is replaced
+// by the replacement character U+FFFD.
+// String is still the raw conversion of the content.
+func (r *RingBuffer) SafeString() string {
+	return strings.ToValidUTF8(r.String(), string(utf8.RuneError))
+}
+
+// ReverseBytes returns a copy of the buffer content in reverse order, from
+// the newest byte to the oldest.
+// The segments are walked backward, filling the result directly.
+func (r *RingBuffer) ReverseBytes() []byte {
+	first, second := r.Segments()
+
+	out := make([]byte, len(first)+len(second))
+	i := 0
+	for j := len(second) - 1; j >= 0; j-- {
+		out[i] = second[j]
+		i++
+	}
+	for j := len(first) - 1; j >= 0; j-- {
+		out[i] = first[j]
+		i++
+	}
+	return out
+}
+
+// MapInPlace replaces every byte of the content with the result of fn on it,
+// from the oldest to the newest, e.g. to mask some characters already
+// written. It works on the underlying buffer, without allocating, and the
+// bytes beyond the content, left by previous writes, are not touched.
+// If the buffer is frozen, it does nothing.
+func (r *RingBuffer) MapInPlace(fn func(b byte) byte) {
+	if r.frozen {
+		return
+	}
+	r.invalidateString()
+
+	first, second := r.Segments()
+	for i, b := range first {
+		first[i] = fn(b)
+	}
+	for i, b := range second {
+		second[i] = fn(b)
+	}
+}
+
+// Chunks returns a copy of the buffer content split in pieces of size bytes,
+// in order. The last piece can be shorter.
+// It returns nil if the buffer is empty or if size is not positive.
+func (r *RingBuffer) Chunks(size int) [][]byte {
+	if size <= 0 {
+		return nil
+	}
+
+	content := r.Bytes()
+	if len(content) == 0 {
+		return nil
+	}
+
+	// rounding up with len(content)+size-1 would overflow for a huge size
+	n := len(content) / size
+	if len(content)%size != 0 {
+		n++
+	}
+
+	chunks := make([][]byte, 0, n)
+	for len(content) > size {
+		// limit the capacity, so appending to a chunk can't overwrite the
+		// next one
+		chunks = append(chunks, content[:size:size])
+		content = content[size:]
+	}
+	return append(chunks, content)
+}
+
+// Len returns the number of bytes of content currently held by the buffer.
+func (r *RingBuffer) Len() int {
+	if r.ringMode {
+		return r.maxSize
+	}
+	return r.pos - r.off
+}
+
+// WouldEvict reports whether writing k more bytes would overwrite some of
+// the content, i.e. whether they don't fit in the space left before the
+// maximum size. In ring mode it is true for any k > 0.
+// It doesn't consider the options changing what Write stores, like
+// WithDedup or WithMaxLineLength.
+func (r *RingBuffer) WouldEvict(k int) bool {
+	return k > 0 && k > r.maxSize-r.Len()
+}
+
+// PrepareWrite grows the underlying buffer, like Grow, so that a following
+// write of size bytes doesn't need to allocate, within the maximum size,
+// and reports whether that write would overwrite some content, like
+// WouldEvict.
+// It returns the error of the allocation, if any, and ErrClosed or ErrFrozen
+// if the buffer can't be written.
+func (r *RingBuffer) PrepareWrite(size int) (willEvict bool, err error) {
+	if err := r.checkWritable(); err != nil {
+		return false, err
+	}
+	if size <= 0 {
+		return false, nil
+	}
+
+	if !r.ringMode {
+		if r.pos+size > len(r.buf) {
+			r.compact()
+		}
+		need := r.pos + size
+		if r.lazyFull || need > r.maxSize {
+			need = r.maxSize
+		}
+		if err := r.Grow(need); err != nil {
+			return false, err
+		}
+	}
+	return r.WouldEvict(size), nil
+}
+
+// Drain discards the oldest n bytes of content without reading them.
+// It returns the number of bytes actually discarded, which is never more than
+// Len().
+// In ring mode the content is first rotated to the beginning of the
+// underlying buffer, so the buffer stops behaving like a ring until it is
+// full again. Otherwise only the start of the content moves, and the room
+// of the drained bytes is reclaimed by the next write needing it.
+// The `written` counter is not affected.
+func (r *RingBuffer) Drain(n int) int {
+	if n <= 0 {
+		return 0
+	}
+
+	if length := r.Len(); n > length {
+		n = length
+	}
+	r.invalidateString()
+
+	lost := r.unreadLost()
+	defer r.skipDiscarded(lost)
+	r.midLine = r.midLineAfter(n)
+
+	// in ring mode, rotate the content in place so that the oldest byte is
+	// at index 0, then it can be handled like the non ring case
+	if r.ringMode {
+		rotateLeft(r.buf, r.pos)
+		r.pos = len(r.buf)
+		r.ringMode = false
+	}
+
+	r.off += n
+	if r.off == r.pos {
+		r.off, r.pos = 0, 0
+	}
+	r.checkDrainBelow()
+	r.checkState()
+	return n
+}
+
+// compact moves the content out of ring mode to the beginning of the
+// underlying buffer, reclaiming the room of the drained bytes.
+func (r *RingBuffer) compact() {
+	if r.off > 0 {
+		r.pos = copy(r.buf, r.buf[r.off:r.pos])
+		r.off = 0
+	}
+}
+
+// RotateLeft cyclically shifts the content by k positions to the left, so
+// that the byte at the logical index k becomes the first one.
+// A negative k rotates to the right. In ring mode only the logical start
+// moves, otherwise the content is rotated in place without allocating.
+// The content keeps its length, but the bytes no longer follow the order
+// they have been written in, so the offsets based methods, like
+// OffsetBytes, don't match the stream of written bytes anymore.
+// If the buffer is frozen, it does nothing.
+func (r *RingBuffer) RotateLeft(k int) {
+	length := r.Len()
+	if r.frozen || length == 0 {
+		return
+	}
+	k %= length
+	if k < 0 {
+		k += length
+	}
+	if k == 0 {
+		return
+	}
+
+	r.invalidateString()
+	if r.ringMode {
+		r.pos = (r.pos + k) % length
+		return
+	}
+	rotateLeft(r.buf[r.off:r.pos], k)
+}
+
+// RotateRight cyclically shifts the content by k positions to the right,
+// like RotateLeft(-k).
+func (r *RingBuffer) RotateRight(k int) {
+	r.RotateLeft(-k)
+}
+
+// rotateLeft rotates in place the slice b by k positions to the left, without
+// allocating.
+func rotateLeft(b []byte, k int) {
+	reverse(b[:k])
+	reverse(b[k:])
+	reverse(b)
+}
+
+// reverse reverses in place the order of the bytes in b.
+func reverse(b []byte) {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+}
+
+// Written returns the number of bytes written so far in the buffer.
+func (r *RingBuffer) Written() int {
 	return r.written
 }
 
+// OldestOffset returns the offset, in the stream of all the bytes written so
+// far, of the oldest byte still held by the buffer.
+// Every byte before this offset has been overwritten, so a consumer can
+// compare it with the last offset it read to detect gaps.
+func (r *RingBuffer) OldestOffset() int64 {
+	return int64(r.written - r.Len())
+}
+
+// Retained reports whether the byte at the given offset, in the stream of all
+// the bytes written so far, is still held by the buffer.
+func (r *RingBuffer) Retained(offset int64) bool {
+	return offset >= r.OldestOffset() && offset < int64(r.written)
+}
+
+// OffsetBytes returns a copy of n bytes starting at the given offset, in the
+// stream of all the bytes written so far.
+// It returns ErrEvicted if some of them have already been overwritten, and
+// ErrOutOfRange if they go beyond Written() or n is negative.
+func (r *RingBuffer) OffsetBytes(offset int64, n int) ([]byte, error) {
+	if offset < r.OldestOffset() {
+		return nil, fmt.Errorf("%w: offset %d, oldest retained %d", ErrEvicted, offset, r.OldestOffset())
+	}
+	if n < 0 || offset > int64(r.written)-int64(n) {
+		return nil, fmt.Errorf("%w: %d bytes at offset %d, written %d", ErrOutOfRange, n, offset, r.written)
+	}
+
+	out := make([]byte, n)
+	r.readAt(out, int(offset-r.OldestOffset()))
+	return out, nil
+}
+
+// Consume returns a copy of the content written since the previous call to
+// Consume, or of the whole content at the first call, without removing it
+// from the buffer.
+// If some of the bytes written since the previous call have already been
+// overwritten, only the retained ones are returned, and the number of lost
+// bytes is reported by GapSinceLastConsume.
+// If the counters have been reset in the meantime, the whole content is
+// returned.
+func (r *RingBuffer) Consume() []byte {
+	oldest, written := r.OldestOffset(), int64(r.written)
+
+	start := r.consumed
+	if start > written {
+		start = oldest
+	}
+
+	r.consumeGap = 0
+	if start < oldest {
+		r.consumeGap = oldest - start
+		start = oldest
+	}
+
+	out := make([]byte, written-start)
+	r.readAt(out, int(start-oldest))
+
+	r.consumed = written
+	return out
+}
+
+// GapSinceLastConsume returns the number of bytes overwritten before the
+// last call to Consume could return them.
+func (r *RingBuffer) GapSinceLastConsume() int64 {
+	return r.consumeGap
+}
+
+// Diff returns a copy of the content written after prev was taken, where
+// prev is an earlier snapshot of the same buffer, e.g. obtained with
+// MarshalBinary and UnmarshalBinary: the bytes from prev.Written() to
+// Written().
+// If some of them have already been overwritten, the retained ones are
+// returned with an error wrapping ErrEvicted, reporting how many were lost.
+// It returns ErrOutOfRange if prev is ahead of the buffer, e.g. because the
+// buffer has been reset after the snapshot.
+func (r *RingBuffer) Diff(prev *RingBuffer) ([]byte, error) {
+	start, oldest := int64(prev.written), r.OldestOffset()
+	if start > int64(r.written) {
+		return nil, fmt.Errorf("%w: snapshot at offset %d, written %d", ErrOutOfRange, start, r.written)
+	}
+
+	var err error
+	if start < oldest {
+		err = fmt.Errorf("%w: %d bytes lost since the snapshot", ErrEvicted, oldest-start)
+		start = oldest
+	}
+
+	out := make([]byte, int64(r.written)-start)
+	r.readAt(out, int(start-oldest))
+	return out, err
+}
+
+// Since returns a copy of the content written after token, together with
+// the token to pass to the next call, e.g. to render a growing log
+// incrementally. A token is an offset in the stream of all the bytes written
+// so far, i.e. Written() when it was returned, so Since(0) returns the whole
+// content.
+// The boolean reports whether all the bytes written after token are still
+// held: if some of them have been overwritten or drained, only the retained
+// ones are returned, and it is false. It is false as well, with the whole
+// content returned, if token is ahead of the buffer because it has been
+// reset; a reset followed by enough writes can't be detected this way, see
+// ReadWithToken.
+func (r *RingBuffer) Since(token uint64) ([]byte, uint64, bool) {
+	oldest, written := r.OldestOffset(), int64(r.written)
+
+	start, retained := int64(token), true
+	if token > uint64(written) || start < oldest {
+		start, retained = oldest, false
+	}
+
+	out := make([]byte, written-start)
+	r.readAt(out, int(start-oldest))
+	return out, uint64(written), retained
+}
+
+// readAt copies into p the content starting from the logical index i, and
+// returns the number of bytes copied.
+func (r *RingBuffer) readAt(p []byte, i int) int {
+	first, second := r.Segments()
+	if i < len(first) {
+		n := copy(p, first[i:])
+		return n + copy(p[n:], second)
+	}
+	return copy(p, second[i-len(first):])
+}
+
+// at returns the byte at the logical index i of the content.
+func (r *RingBuffer) at(i int) byte {
+	first, second := r.Segments()
+	if i < len(first) {
+		return first[i]
+	}
+	return second[i-len(first)]
+}
+
+// First returns the oldest byte held by the buffer.
+// The boolean is false if the buffer is empty.
+func (r *RingBuffer) First() (byte, bool) {
+	if r.Len() == 0 {
+		return 0, false
+	}
+	return r.at(0), true
+}
+
+// Last returns the newest byte held by the buffer.
+// The boolean is false if the buffer is empty.
+func (r *RingBuffer) Last() (byte, bool) {
+	n := r.Len()
+	if n == 0 {
+		return 0, false
+	}
+	return r.at(n - 1), true
+}
+
+// Validate checks the internal invariants of the buffer, returning an error
+// describing the first violation found, if any.
+// It is meant to be used in tests and fuzzers: a RingBuffer used only through
+// its methods should always be valid.
+func (r *RingBuffer) Validate() error {
+	if r.maxSize < 0 {
+		return fmt.Errorf("%w: negative maxSize %d", ErrInvalidState, r.maxSize)
+	}
+	if len(r.buf) > r.maxSize {
+		return fmt.Errorf("%w: buffer length %d exceeds maxSize %d", ErrInvalidState, len(r.buf), r.maxSize)
+	}
+	if r.pos < 0 || r.pos > len(r.buf) {
+		return fmt.Errorf("%w: pos %d out of range [0, %d]", ErrInvalidState, r.pos, len(r.buf))
+	}
+	if r.off < 0 || r.off > r.pos || r.ringMode && r.off != 0 {
+		return fmt.Errorf("%w: off %d out of range [0, %d]", ErrInvalidState, r.off, r.pos)
+	}
+	if r.ringMode && len(r.buf) != r.maxSize {
+		return fmt.Errorf("%w: ring mode with buffer length %d different from maxSize %d", ErrInvalidState, len(r.buf), r.maxSize)
+	}
+	if r.written < r.Len() {
+		return fmt.Errorf("%w: written %d lower than length %d", ErrInvalidState, r.written, r.Len())
+	}
+	return nil
+}
+
+// Swap exchanges the whole state of r and other, content and counters
+// included, without copying the underlying buffers.
+// It can be used for double buffering, writing to a buffer while reading the
+// other one.
+func (r *RingBuffer) Swap(other *RingBuffer) {
+	*r, *other = *other, *r
+}
+
+// ReplaceRange replaces the bytes of the content in the logical range
+// [start, end) with the bytes of with, which can have a different length:
+// the content after the range is shifted accordingly.
+// If the new content is longer than the maximum size, the oldest bytes are
+// dropped, like a write would do, otherwise the buffer stops behaving like
+// a ring until it is full again, as after Drain.
+// The `written` counter changes by the difference of length, so that
+// OldestOffset still accounts for the dropped bytes.
+// It returns ErrOutOfRange if the range is not within the content, and
+// ErrClosed or ErrFrozen if the buffer can't be written.
+func (r *RingBuffer) ReplaceRange(start, end int, with []byte) error {
+	if err := r.checkWritable(); err != nil {
+		return err
+	}
+	length := r.Len()
+	if start < 0 || start > end || end > length {
+		return fmt.Errorf("%w: range [%d, %d) of %d bytes", ErrOutOfRange, start, end, length)
+	}
+
+	old := r.Bytes()
+	content := make([]byte, 0, length-(end-start)+len(with))
+	content = append(content, old[:start]...)
+	content = append(content, with...)
+	content = append(content, old[end:]...)
+	midLine := r.midLine
+	if drop := len(content) - r.maxSize; drop > 0 {
+		midLine = content[drop-1] != '\n'
+		content = content[drop:]
+	}
+
+	if err := r.Grow(len(content)); err != nil {
+		return err
+	}
+	r.midLine = midLine
+
+	r.invalidateString()
+
+	lost := r.unreadLost()
+	r.written += len(with) - (end - start)
+	r.off = 0
+	r.pos = copy(r.buf, content)
+	r.ringMode = false
+	r.skipDiscarded(lost)
+	r.checkDrainBelow()
+	r.checkState()
+	return nil
+}
+
+// SplitAt returns two new buffers, each with its own underlying slice and
+// a maximum size of maxSize: head holding the first n bytes of the content,
+// and tail holding the rest. n is limited to the range [0, Len()].
+// A half longer than maxSize keeps only its most recent bytes, as if it had
+// been written into a buffer of that size.
+// The written counter of each one is the length of its half, so that
+// OldestOffset reports the bytes left out.
+// r is left unchanged, it can be cleared with Reset if it has to be
+// consumed.
+func (r *RingBuffer) SplitAt(n, maxSize int) (head, tail *RingBuffer) {
+	n = clamp(n, 0, r.Len())
+	if maxSize < 0 {
+		maxSize = 0
+	}
+
+	return r.split(0, n, maxSize), r.split(n, r.Len(), maxSize)
+}
+
+// split returns a new buffer with a maximum size of maxSize holding the
+// bytes of the content in [from, to), or their most recent maxSize ones.
+func (r *RingBuffer) split(from, to, maxSize int) *RingBuffer {
+	kept := clamp(from, to-maxSize, to)
+
+	b := NewRingBuffer(to-kept, maxSize)
+	b.pos = r.readAt(b.buf, kept)
+	b.written = to - from
+	b.midLine = r.midLineAfter(kept)
+	return b
+}
+
+// MaxSize returns the maximum size the buffer can reach.
+func (r *RingBuffer) MaxSize() int {
+	return r.maxSize
+}
+
+// SetMaxSize changes the maximum size the buffer can reach.
+// If the new limit is lower than the current content length, only the
+// newest maxSize bytes are kept. Whenever the capacity of the underlying
+// buffer exceeds the new limit, it is reallocated to release that memory.
+// The content is moved to the beginning of the underlying buffer, so the
+// buffer stops behaving like a ring until it is full again.
+// A negative maxSize is considered 0. If the buffer is frozen, it returns
+// ErrFrozen.
+func (r *RingBuffer) SetMaxSize(maxSize int) error {
+	if err := r.checkWritable(); err != nil {
+		return err
+	}
+	if maxSize < 0 {
+		maxSize = 0
+	}
+
+	if maxSize >= len(r.buf) {
+		// the content fits, just make it contiguous
+		if r.ringMode {
+			rotateLeft(r.buf, r.pos)
+			r.pos = len(r.buf)
+			r.ringMode = false
+		}
+		r.compact()
+
+		// a backing array larger than the new limit, e.g. adopted or left
+		// by a bigger maximum size, is replaced to actually release it
+		if cap(r.buf) > maxSize {
+			newBuf, err := r.allocate(len(r.buf))
+			if err != nil {
+				return err
+			}
+			copy(newBuf, r.buf[:r.pos])
+			r.buf = newBuf
+		}
+		r.maxSize = maxSize
+		r.checkDrainBelow()
+		r.checkState()
+		return nil
+	}
+
+	newBuf, err := r.allocate(maxSize)
+	if err != nil {
+		return err
+	}
+
+	r.invalidateString()
+	r.midLine = r.midLineAfter(r.Len() - maxSize)
+	first, second := r.Segments()
+	if drop := len(first) + len(second) - maxSize; drop > 0 {
+		// skip the oldest bytes that don't fit
+		if drop >= len(first) {
+			second = second[drop-len(first):]
+			first = nil
+		} else {
+			first = first[drop:]
+		}
+	}
+	n := copy(newBuf, first)
+	n += copy(newBuf[n:], second)
+
+	lost := r.unreadLost()
+	r.buf = newBuf
+	r.off = 0
+	r.pos = n
+	r.ringMode = false
+	r.maxSize = maxSize
+	r.skipDiscarded(lost)
+	r.checkDrainBelow()
+	r.checkState()
+	return nil
+}
+
 // Reset clears the buffer.
 // The underlying slice is kept, so it keeps the same size reached so far.
+// The old bytes are still physically there, but every read path only looks
+// at the content written after the reset.
 // The `written` counter is reset too.
 func (r *RingBuffer) Reset() {
+	if r.frozen {
+		return
+	}
+	r.invalidateString()
 	r.written = 0
 	r.ringMode = false
+	r.off = 0
+	r.pos = 0
+	r.midLine = false
+	r.generation++
+
+	// offsets restart from 0, so the consume watermark must too
+	r.consumed = 0
+	r.consumeGap = 0
+	r.readNext = 0
+	r.readGap = 0
+	if r.evictions != nil {
+		*r.evictions = evictionStats{}
+	}
+	r.sources = r.sources[:0]
+	r.forgetPending()
+	r.checkDrainBelow()
+	r.checkState()
+}
+
+// ResetFull clears the buffer like Reset and also restores every option to
+// its default, as if the buffer had just been created with no options, e.g.
+// so that a pooled buffer doesn't leak its configuration to the next user.
+// The callbacks set by OnLine and OnDrainBelow are removed, the counters of
+// the rate and latency tracking are dropped and a frozen buffer becomes
+// writable again.
+// The underlying slice and the maximum size are kept.
+// The spill destination set with WithSpill is flushed and closed, like by
+// Close, so that the bytes it buffered are not lost, and its error is
+// returned; the buffer is reset anyway.
+func (r *RingBuffer) ResetFull() error {
+	err := r.closeSpill()
+	*r = RingBuffer{
+		buf:        r.buf,
+		maxSize:    r.maxSize,
+		closed:     r.closed,
+		alloc:      r.alloc,
+		now:        r.now,
+		generation: r.generation + 1,
+	}
+	return err
+}
+
+// TakeBytes returns a copy of the buffer content and clears the buffer with
+// Reset, in one call, e.g. to rotate a log.
+// If the buffer is frozen, the content is returned but not cleared.
+func (r *RingBuffer) TakeBytes() []byte {
+	out := r.Bytes()
+	r.Reset()
+	return out
+}
+
+// ResetZero clears the buffer like Reset, and also overwrites with zeros the
+// whole underlying slice, so no old content is left in memory.
+func (r *RingBuffer) ResetZero() {
+	if r.frozen {
+		return
+	}
+	for i := range r.buf {
+		r.buf[i] = 0
+	}
+	r.Reset()
+}
+
+// ClearContent clears the buffer content, like Reset, but keeps the
+// `written` counter, so it can still be used for lifetime statistics.
+func (r *RingBuffer) ClearContent() {
+	if r.frozen {
+		return
+	}
+	r.invalidateString()
+
+	lost := r.unreadLost()
+	r.midLine = r.midLineAfter(r.Len())
+	r.ringMode = false
+	r.off = 0
 	r.pos = 0
+	r.skipDiscarded(lost)
+	r.forgetPending()
+	r.checkDrainBelow()
+	r.checkState()
+}
+
+// forgetPending discards the state kept about the content written before a
+// reset: the partial line waiting for OnLine and the last chunk compared by
+// WithDedup, otherwise the old content could be glued to the new one or make
+// a fresh write be skipped as a repeat.
+func (r *RingBuffer) forgetPending() {
+	r.partialLine = r.partialLine[:0]
+	r.lastChunk = nil
+	r.lineLen = 0
+}
+
+// ResetStats resets the `written` counter, keeping the buffer content.
+// The counter restarts from the length of the content still held, so it
+// never reports less bytes than the ones the buffer contains.
+func (r *RingBuffer) ResetStats() {
+	r.written = r.Len()
+	if r.evictions != nil {
+		*r.evictions = evictionStats{}
+	}
 }
 
 // NewRingBuffer creates and initialise a new RingBuffer using
@@ -214,16 +1958,61 @@ func (r *RingBuffer) Reset() {
 // - maxSize as maximum limit this buffer can reach.
 //
 // If initial is greater than cap, cap is used as size.
-func NewRingBuffer(initialSize, maxSize int) *RingBuffer {
+// Negative sizes are considered 0, so they never make it panic.
+// A buffer with a maxSize of 0 is a counting sink: writes succeed, the
+// content is always empty and Written still grows.
+// Optional behaviours can be configured with opts.
+func NewRingBuffer(initialSize, maxSize int, opts ...Option) *RingBuffer {
+	if maxSize < 0 {
+		maxSize = 0
+	}
+	if initialSize < 0 {
+		initialSize = 0
+	}
 	if initialSize > maxSize {
 		initialSize = maxSize
 	}
 
-	return &RingBuffer{
+	r := &RingBuffer{
 		buf:      make([]byte, initialSize, initialSize),
 		written:  0,
 		ringMode: false,
 		pos:      0,
 		maxSize:  maxSize,
 	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// NewRingBufferUsing creates and initialise a new RingBuffer adopting buf as
+// the underlying buffer, instead of allocating a new one.
+// len(buf) is used as initial size, limited to maxSize. If the capacity of
+// buf is greater than maxSize, a new slice is allocated instead, so that the
+// memory that could never be used is not retained. A negative maxSize is
+// considered 0.
+//
+// The RingBuffer takes ownership of buf: the caller must not use it after
+// this call.
+// Optional behaviours can be configured with opts.
+func NewRingBufferUsing(buf []byte, maxSize int, opts ...Option) *RingBuffer {
+	if maxSize < 0 {
+		maxSize = 0
+	}
+	if cap(buf) > maxSize {
+		buf = make([]byte, clamp(len(buf), 0, maxSize))
+	}
+
+	r := &RingBuffer{
+		buf:      buf,
+		written:  0,
+		ringMode: false,
+		pos:      0,
+		maxSize:  maxSize,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
@@ -1,9 +1,16 @@
 package ringbuffer
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
 )
 
 func BenchmarkReadAsString(b *testing.B) {
@@ -67,6 +74,42 @@ func TestNewRingBuffer(t *testing.T) {
 				maxSize:  10,
 			},
 		},
+		{
+			name:        "negative initialSize",
+			initialSize: -1,
+			maxSize:     10,
+			want: &RingBuffer{
+				buf:      make([]byte, 0),
+				pos:      0,
+				written:  0,
+				ringMode: false,
+				maxSize:  10,
+			},
+		},
+		{
+			name:        "negative maxSize",
+			initialSize: 5,
+			maxSize:     -3,
+			want: &RingBuffer{
+				buf:      make([]byte, 0),
+				pos:      0,
+				written:  0,
+				ringMode: false,
+				maxSize:  0,
+			},
+		},
+		{
+			name:        "both negative",
+			initialSize: -5,
+			maxSize:     -3,
+			want: &RingBuffer{
+				buf:      make([]byte, 0),
+				pos:      0,
+				written:  0,
+				ringMode: false,
+				maxSize:  0,
+			},
+		},
 	}
 	for _, tt := range tests {
 		var tt = tt
@@ -83,6 +126,274 @@ func TestNewRingBuffer(t *testing.T) {
 	}
 }
 
+func TestNewRingBufferUsing(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+
+		buf     []byte
+		maxSize int
+
+		want    *RingBuffer
+		wantCap int
+	}{
+		{
+			name:    "ok",
+			buf:     make([]byte, 10),
+			maxSize: 20,
+			want: &RingBuffer{
+				buf:      make([]byte, 10),
+				pos:      0,
+				written:  0,
+				ringMode: false,
+				maxSize:  20,
+			},
+			wantCap: 10,
+		},
+		{
+			name:    "len greater than cap",
+			buf:     make([]byte, 20),
+			maxSize: 10,
+			want: &RingBuffer{
+				buf:      make([]byte, 10),
+				pos:      0,
+				written:  0,
+				ringMode: false,
+				maxSize:  10,
+			},
+			wantCap: 10,
+		},
+		{
+			name:    "cap greater than maxSize",
+			buf:     make([]byte, 4, 100),
+			maxSize: 8,
+			want: &RingBuffer{
+				buf:      make([]byte, 4),
+				pos:      0,
+				written:  0,
+				ringMode: false,
+				maxSize:  8,
+			},
+			wantCap: 4,
+		},
+		{
+			name:    "negative maxSize",
+			buf:     make([]byte, 4),
+			maxSize: -1,
+			want: &RingBuffer{
+				buf:      make([]byte, 0),
+				pos:      0,
+				written:  0,
+				ringMode: false,
+				maxSize:  0,
+			},
+			wantCap: 0,
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := NewRingBufferUsing(tt.buf, tt.maxSize)
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewRingBufferUsing() got = %v, want %v", got, tt.want)
+			}
+			if got := got.Cap(); got != tt.wantCap {
+				t.Errorf("Cap() got = %d, want %d", got, tt.wantCap)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_SetMaxSize_releasesMemory(t *testing.T) {
+	t.Parallel()
+
+	adopted := make([]byte, 2, 1000)
+	r := &RingBuffer{
+		buf:     adopted,
+		pos:     2,
+		written: 2,
+		maxSize: 1000,
+	}
+	copy(adopted, "ab")
+
+	if err := r.SetMaxSize(10); err != nil {
+		t.Fatalf("SetMaxSize() error = %v", err)
+	}
+	if got := r.Cap(); got != 2 {
+		t.Errorf("Cap() got = %d, want 2", got)
+	}
+	if &r.Buffer()[0] == &adopted[0] {
+		t.Errorf("SetMaxSize() kept the backing array of %d bytes", cap(adopted))
+	}
+	if got := r.String(); got != "ab" {
+		t.Errorf("String() got = %q, want %q", got, "ab")
+	}
+}
+
+func TestNewRingBufferUsing_NoAllocation(t *testing.T) {
+	scratch := make([]byte, 8)
+
+	// the only allocation allowed is the RingBuffer struct itself
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = NewRingBufferUsing(scratch, 8)
+	})
+	if allocs > 1 {
+		t.Errorf("NewRingBufferUsing() allocations got = %v, want <= 1", allocs)
+	}
+
+	r := NewRingBufferUsing(scratch, 8)
+	_, _ = r.Write([]byte("abc"))
+
+	if &r.buf[0] != &scratch[0] {
+		t.Errorf("underlying buffer is not the provided slice")
+	}
+
+	if got := string(scratch[:3]); got != "abc" {
+		t.Errorf("provided slice got = %q, want %q", got, "abc")
+	}
+}
+
+func TestNewRingBuffer_ZeroMaxSize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		write func(r *RingBuffer) (int, error)
+		want  int
+	}{
+		{"Write", func(r *RingBuffer) (int, error) { return r.Write([]byte("abc")) }, 3},
+		{"WriteString", func(r *RingBuffer) (int, error) { return r.WriteString("abcd") }, 4},
+		{"WriteByte", func(r *RingBuffer) (int, error) { return 1, r.WriteByte('a') }, 1},
+		{"WriteMulti", func(r *RingBuffer) (int, error) {
+			n, err := r.WriteMulti([]byte("ab"), []byte("cde"))
+			return int(n), err
+		}, 5},
+	}
+	for _, tt := range tests {
+		var tt = tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(0, 0)
+			for i := 1; i <= 2; i++ {
+				n, err := tt.write(r)
+				if err != nil {
+					t.Fatalf("%s() error = %v, want nil", tt.name, err)
+				}
+				if n != tt.want {
+					t.Errorf("%s() got = %v, want %v", tt.name, n, tt.want)
+				}
+				if got := r.Len(); got != 0 {
+					t.Errorf("Len() got = %v, want 0", got)
+				}
+				if got := r.String(); got != "" {
+					t.Errorf("String() got = %q, want empty", got)
+				}
+				if got := r.Written(); got != i*tt.want {
+					t.Errorf("Written() got = %v, want %v", got, i*tt.want)
+				}
+			}
+
+			if n, err := r.Read(make([]byte, 4)); n != 0 || err != io.EOF {
+				t.Errorf("Read() got = %v, %v, want 0, %v", n, err, io.EOF)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_Write_NoAllocation(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   []Option
+		onLine bool
+	}{
+		{name: "no options"},
+		{name: "line callback", onLine: true},
+	}
+	for _, tt := range tests {
+		r := NewRingBuffer(8, 8, tt.opts...)
+		if tt.onLine {
+			r.OnLine(func([]byte) {})
+		}
+
+		// fill the buffer and the pending line once, so that nothing
+		// grows afterwards
+		_, _ = r.Write([]byte("abcdefgh\nab\n"))
+
+		allocs := testing.AllocsPerRun(100, func() {
+			_, _ = r.Write([]byte("ab\n"))
+			_ = r.WriteByte('c')
+			_, _ = r.WriteString("d\n")
+		})
+		if allocs != 0 {
+			t.Errorf("%s: Write() allocations got = %v, want 0", tt.name, allocs)
+		}
+	}
+}
+
+func TestRingBuffer_WriteString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		before string
+		drain  int
+		s      string
+	}{
+		{name: "empty buffer", s: "abc"},
+		{name: "growing", before: "ab", s: "cdef"},
+		{name: "exactly full", before: "ab", s: "cdefgh"},
+		{name: "entering ring mode", before: "abcde", s: "fghij"},
+		{name: "ring mode, contiguous", before: "abcdefghi", s: "jk"},
+		{name: "ring mode, wrapping", before: "abcdefghijklmno", s: "pqrst"},
+		{name: "ring mode, whole buffer", before: "abcdefghij", s: "klmnopqr"},
+		{name: "after a drain", before: "abcdefgh", drain: 3, s: "ijkl"},
+		{name: "longer than the maximum size", before: "abc", s: "defghijklmno"},
+		{name: "empty string", before: "abc", s: ""},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			want := NewRingBuffer(0, 8)
+			got := NewRingBuffer(0, 8)
+			for _, r := range []*RingBuffer{want, got} {
+				_, _ = r.Write([]byte(tt.before))
+				r.Drain(tt.drain)
+			}
+
+			wantN, wantErr := want.Write([]byte(tt.s))
+			gotN, gotErr := got.WriteString(tt.s)
+
+			if gotN != wantN || gotErr != wantErr {
+				t.Errorf("WriteString() got = %d, %v, want %d, %v", gotN, gotErr, wantN, wantErr)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("WriteString() got = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_WriteString_NoAllocation(t *testing.T) {
+	r := NewRingBuffer(256, 256)
+	s := strings.Repeat("abcdefghij", 10)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		_, _ = r.WriteString(s)
+	})
+	if allocs != 0 {
+		t.Errorf("WriteString() allocations got = %v, want 0", allocs)
+	}
+}
+
 func TestRingBuffer_Write(t *testing.T) {
 	t.Parallel()
 
@@ -185,10 +496,47 @@ func TestRingBuffer_Write(t *testing.T) {
 			toWrite:     []byte("abcdefg"),
 			wantBuffer: &RingBuffer{
 				buf:      []byte{'a', 'b', 'c', 'd', 'e', 'f', 'g'},
-				pos:      0,
+				pos:      7,
+				written:  7,
+				ringMode: false,
+				maxSize:  7,
+			},
+		},
+		{
+			name: "write after filling cap, start ring mode",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', 'c', 'd', 'e', 'f', 'g'},
+				pos:      7,
 				written:  7,
+				ringMode: false,
+				maxSize:  7,
+			},
+			toWrite: []byte("hi"),
+			wantBuffer: &RingBuffer{
+				buf:      []byte{'h', 'i', 'c', 'd', 'e', 'f', 'g'},
+				pos:      2,
+				written:  9,
 				ringMode: true,
 				maxSize:  7,
+				midLine:  true,
+			},
+		},
+		{
+			name: "write empty slice after filling cap, no ring mode",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', 'c', 'd', 'e', 'f', 'g'},
+				pos:      7,
+				written:  7,
+				ringMode: false,
+				maxSize:  7,
+			},
+			toWrite: []byte{},
+			wantBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', 'c', 'd', 'e', 'f', 'g'},
+				pos:      7,
+				written:  7,
+				ringMode: false,
+				maxSize:  7,
 			},
 		},
 		{
@@ -201,6 +549,7 @@ func TestRingBuffer_Write(t *testing.T) {
 				written:  11,
 				ringMode: true,
 				maxSize:  7,
+				midLine:  true,
 			},
 		},
 		{
@@ -219,6 +568,7 @@ func TestRingBuffer_Write(t *testing.T) {
 				written:  17,
 				ringMode: true,
 				maxSize:  7,
+				midLine:  true,
 			},
 		},
 		{
@@ -237,6 +587,7 @@ func TestRingBuffer_Write(t *testing.T) {
 				written:  19,
 				ringMode: true,
 				maxSize:  7,
+				midLine:  true,
 			},
 		},
 		{
@@ -255,6 +606,7 @@ func TestRingBuffer_Write(t *testing.T) {
 				written:  20,
 				ringMode: true,
 				maxSize:  7,
+				midLine:  true,
 			},
 		},
 		{
@@ -267,6 +619,7 @@ func TestRingBuffer_Write(t *testing.T) {
 				written:  26,
 				ringMode: true,
 				maxSize:  7,
+				midLine:  true,
 			},
 		},
 	}
@@ -396,6 +749,62 @@ func TestRingBuffer_String(t *testing.T) {
 	}
 }
 
+func TestRingBuffer_SafeString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		size   int
+		writes []string
+		want   string
+	}{
+		{
+			name:   "valid",
+			size:   16,
+			writes: []string{"héllo, 世界"},
+			want:   "héllo, 世界",
+		},
+		{
+			name:   "binary",
+			size:   16,
+			writes: []string{"a\xff\xfeb\x80"},
+			want:   "a\uFFFDb\uFFFD",
+		},
+		{
+			name:   "rune chopped by eviction",
+			size:   4,
+			writes: []string{"a世", "bc"},
+			want:   "\uFFFDbc",
+		},
+		{
+			name:   "rune chopped at the end",
+			size:   8,
+			writes: []string{"ab", "\xe4\xb8"},
+			want:   "ab\uFFFD",
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(0, tt.size)
+			for _, w := range tt.writes {
+				_, _ = r.Write([]byte(w))
+			}
+
+			got := r.SafeString()
+			if got != tt.want {
+				t.Errorf("SafeString() got = %q, want %q", got, tt.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("SafeString() got = %q, not valid UTF-8", got)
+			}
+		})
+	}
+}
+
 func TestRingBuffer_Bytes(t *testing.T) {
 	t.Parallel()
 
@@ -497,33 +906,20 @@ func TestRingBuffer_Bytes(t *testing.T) {
 	}
 }
 
-func TestRingBuffer_Close(t *testing.T) {
+func TestRingBuffer_BytesNL(t *testing.T) {
 	t.Parallel()
 
 	tests := []struct {
-		name        string
-		inputBuffer *RingBuffer
-		wantBuffer  *RingBuffer
-		wantErr     bool
+		name   string
+		writes []string
+		want   string
 	}{
-		{
-			name: "ok",
-			inputBuffer: &RingBuffer{
-				buf:      []byte{'e', 'b', 'c', 'd'},
-				pos:      1,
-				written:  5,
-				ringMode: true,
-				maxSize:  4,
-			},
-			wantBuffer: &RingBuffer{
-				buf:      nil,
-				pos:      0,
-				written:  0,
-				ringMode: false,
-				maxSize:  0,
-			},
-			wantErr: false,
-		},
+		{name: "empty", want: ""},
+		{name: "without newline", writes: []string{"ab\ncd"}, want: "ab\ncd\n"},
+		{name: "with newline", writes: []string{"ab\ncd\n"}, want: "ab\ncd\n"},
+		{name: "multiple newlines", writes: []string{"ab\n\n\n"}, want: "ab\n\n\n"},
+		{name: "only a newline", writes: []string{"\n"}, want: "\n"},
+		{name: "ring mode", writes: []string{"abcdef\n", "gh"}, want: "def\ngh\n"},
 	}
 	for _, tt := range tests {
 		var tt = tt
@@ -531,43 +927,166 @@ func TestRingBuffer_Close(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
-			gotErr := tt.inputBuffer.Close()
-
-			if (gotErr != nil) != tt.wantErr {
-				t.Errorf("Close() error = %v, wantErr %v", gotErr, tt.wantErr)
+			r := NewRingBuffer(0, 6)
+			for _, w := range tt.writes {
+				_, _ = r.Write([]byte(w))
 			}
+			before := r.String()
 
-			if !reflect.DeepEqual(tt.inputBuffer, tt.wantBuffer) {
-				t.Errorf("Write() got = %+v want %+v", tt.inputBuffer, tt.wantBuffer)
+			if got := string(r.BytesNL()); got != tt.want {
+				t.Errorf("BytesNL() got = %q, want %q", got, tt.want)
+			}
+			if got := r.String(); got != before {
+				t.Errorf("String() after BytesNL got = %q, want %q", got, before)
 			}
 		})
 	}
 }
 
-func TestRingBuffer_Reset(t *testing.T) {
+func TestRingBuffer_SectionReader(t *testing.T) {
 	t.Parallel()
 
 	tests := []struct {
-		name        string
-		inputBuffer *RingBuffer
-		wantBuffer  *RingBuffer
+		name  string
+		off   int64
+		n     int64
+		want  string
+		write string
 	}{
-		{
-			name: "ok",
-			inputBuffer: &RingBuffer{
-				buf:      []byte{'e', 'b', 'c', 'd'},
-				pos:      1,
-				written:  5,
-				ringMode: true,
-				maxSize:  4,
-			},
-			wantBuffer: &RingBuffer{
-				buf:      []byte{'e', 'b', 'c', 'd'},
-				pos:      0,
-				written:  0,
-				ringMode: false,
+		{name: "whole content", off: 0, n: 7, want: "defghij"},
+		{name: "spanning the wrap", off: 2, n: 4, want: "fghi"},
+		{name: "beyond the end", off: 5, n: 10, want: "ij"},
+		{name: "offset beyond the end", off: 8, n: 2, want: ""},
+		{name: "negative offset", off: -1, n: 2, want: ""},
+		{name: "negative length", off: 1, n: -2, want: ""},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// content "defghij", wrapped after 'g'
+			r := NewRingBuffer(7, 7)
+			_, _ = r.Write([]byte("abcdefghij"))
+
+			sr := r.SectionReader(tt.off, tt.n)
+
+			// later writes must not change the section
+			_, _ = r.Write([]byte("0123456"))
+
+			if sr.Size() != int64(len(tt.want)) {
+				t.Errorf("Size() got = %d, want %d", sr.Size(), len(tt.want))
+			}
+
+			var got bytes.Buffer
+			if _, err := got.ReadFrom(sr); err != nil {
+				t.Fatalf("ReadFrom() error = %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("SectionReader() got = %q, want %q", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_SectionReader_ReadAt(t *testing.T) {
+	t.Parallel()
+
+	r := NewRingBuffer(7, 7)
+	_, _ = r.Write([]byte("abcdefghij"))
+	sr := r.SectionReader(1, 5)
+
+	p := make([]byte, 3)
+	if n, err := sr.ReadAt(p, 2); err != nil || string(p[:n]) != "ghi" {
+		t.Errorf("ReadAt() got = %q, %v, want %q, nil", p[:n], err, "ghi")
+	}
+	if n, err := sr.ReadAt(p, 3); err != io.EOF || string(p[:n]) != "hi" {
+		t.Errorf("ReadAt() got = %q, %v, want %q, EOF", p[:n], err, "hi")
+	}
+
+	if _, err := sr.Seek(-2, io.SeekEnd); err != nil {
+		t.Fatalf("Seek() error = %v", err)
+	}
+	if n, _ := sr.Read(p); string(p[:n]) != "hi" {
+		t.Errorf("Read() after Seek got = %q, want %q", p[:n], "hi")
+	}
+}
+
+func TestRingBuffer_Close(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		inputBuffer *RingBuffer
+		wantBuffer  *RingBuffer
+		wantErr     bool
+	}{
+		{
+			name: "ok",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'e', 'b', 'c', 'd'},
+				pos:      1,
+				written:  5,
+				ringMode: true,
+				maxSize:  4,
+			},
+			wantBuffer: &RingBuffer{
+				buf:        nil,
+				pos:        0,
+				written:    0,
+				ringMode:   false,
+				maxSize:    0,
+				closed:     true,
+				generation: 1,
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			gotErr := tt.inputBuffer.Close()
+
+			if (gotErr != nil) != tt.wantErr {
+				t.Errorf("Close() error = %v, wantErr %v", gotErr, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(tt.inputBuffer, tt.wantBuffer) {
+				t.Errorf("Write() got = %+v want %+v", tt.inputBuffer, tt.wantBuffer)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_Reset(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		inputBuffer *RingBuffer
+		wantBuffer  *RingBuffer
+	}{
+		{
+			name: "ok",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'e', 'b', 'c', 'd'},
+				pos:      1,
+				written:  5,
+				ringMode: true,
 				maxSize:  4,
 			},
+			wantBuffer: &RingBuffer{
+				buf:        []byte{'e', 'b', 'c', 'd'},
+				pos:        0,
+				written:    0,
+				ringMode:   false,
+				maxSize:    4,
+				generation: 1,
+			},
 		},
 	}
 	for _, tt := range tests {
@@ -583,3 +1102,4349 @@ func TestRingBuffer_Reset(t *testing.T) {
 		})
 	}
 }
+
+func TestRingBuffer_ReadWithToken(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		steps       func(r *RingBuffer)
+		wantContent []byte
+		wantChanged bool
+	}{
+		{
+			name:        "write",
+			steps:       func(r *RingBuffer) { _, _ = r.Write([]byte("cdef")) },
+			wantContent: []byte("cdef"),
+			wantChanged: false,
+		},
+		{
+			name:        "drained by Read",
+			steps:       func(r *RingBuffer) { _, _ = r.Read(make([]byte, 2)) },
+			wantContent: []byte{},
+			wantChanged: false,
+		},
+		{
+			name: "reset",
+			steps: func(r *RingBuffer) {
+				r.Reset()
+			},
+			wantContent: []byte{},
+			wantChanged: true,
+		},
+		{
+			name: "reset and written again",
+			steps: func(r *RingBuffer) {
+				r.Reset()
+				_, _ = r.Write([]byte("ab"))
+			},
+			wantContent: []byte("ab"),
+			wantChanged: true,
+		},
+		{
+			name:        "full reset",
+			steps:       func(r *RingBuffer) { r.ResetFull() },
+			wantContent: []byte{},
+			wantChanged: true,
+		},
+		{
+			name:        "closed",
+			steps:       func(r *RingBuffer) { _ = r.Close() },
+			wantContent: []byte{},
+			wantChanged: true,
+		},
+		{
+			name: "reset of a frozen buffer",
+			steps: func(r *RingBuffer) {
+				r.Freeze()
+				r.Reset()
+			},
+			wantContent: []byte("ab"),
+			wantChanged: false,
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(0, 4)
+			_, _ = r.Write([]byte("ab"))
+			_, before := r.ReadWithToken()
+
+			tt.steps(r)
+
+			content, after := r.ReadWithToken()
+			if !reflect.DeepEqual(content, tt.wantContent) {
+				t.Errorf("ReadWithToken() content got = %q, want %q", content, tt.wantContent)
+			}
+			if changed := after != before; changed != tt.wantChanged {
+				t.Errorf("ReadWithToken() token changed got = %v, want %v", changed, tt.wantChanged)
+			}
+			if after < before {
+				t.Errorf("ReadWithToken() token got = %d, want >= %d", after, before)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_Grow(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		initialSize int
+		size        int
+		wantCap     int
+	}{
+		{
+			name:        "negative",
+			initialSize: 2,
+			size:        -5,
+			wantCap:     2,
+		},
+		{
+			name:        "zero",
+			initialSize: 2,
+			size:        0,
+			wantCap:     2,
+		},
+		{
+			name:        "zero on empty buffer",
+			initialSize: 0,
+			size:        0,
+			wantCap:     0,
+		},
+		{
+			name:        "double",
+			initialSize: 2,
+			size:        3,
+			wantCap:     4,
+		},
+		{
+			name:        "beyond maxSize",
+			initialSize: 2,
+			size:        100,
+			wantCap:     10,
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(tt.initialSize, 10)
+			if err := r.Grow(tt.size); err != nil {
+				t.Fatalf("Grow() error = %v", err)
+			}
+			if got := r.Cap(); got != tt.wantCap {
+				t.Errorf("Cap() got = %d, want %d", got, tt.wantCap)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_Grow_capacityHeadroom(t *testing.T) {
+	scratch := make([]byte, 0, 64)
+	r := NewRingBufferUsing(scratch, 64)
+	p := []byte("abc")
+
+	allocs := testing.AllocsPerRun(10, func() {
+		r.buf = r.buf[:0]
+		r.Reset()
+		_, _ = r.Write(p)
+		_ = r.Grow(40)
+	})
+	if allocs != 0 {
+		t.Errorf("Grow() allocations got = %v, want 0", allocs)
+	}
+
+	if len(r.buf) != 64 || &r.buf[0] != &scratch[:1][0] {
+		t.Errorf("Grow() got len %d, want 64 in the provided backing array", len(r.buf))
+	}
+	if got := r.String(); got != "abc" {
+		t.Errorf("String() got = %q, want %q", got, "abc")
+	}
+
+	// beyond the capacity, it reallocates
+	r = NewRingBufferUsing(make([]byte, 2, 4), 16)
+	_, _ = r.Write([]byte("ab"))
+	if err := r.Grow(5); err != nil || len(r.buf) != 8 || cap(r.buf) != 8 {
+		t.Errorf("Grow() got len %d, cap %d, err %v, want 8, 8, nil", len(r.buf), cap(r.buf), err)
+	}
+	if got := r.String(); got != "ab" {
+		t.Errorf("String() got = %q, want %q", got, "ab")
+	}
+}
+
+func TestRingBuffer_WriteTracked(t *testing.T) {
+	t.Parallel()
+
+	type rng struct {
+		start, end int64
+	}
+
+	tests := []struct {
+		name   string
+		opts   []Option
+		writes []string
+		want   []rng
+	}{
+		{
+			name:   "contiguous ranges",
+			writes: []string{"ab", "cde", "", "f"},
+			want:   []rng{{0, 2}, {2, 5}, {5, 5}, {5, 6}},
+		},
+		{
+			name:   "independent of eviction",
+			writes: []string{"abc", "defgh", "012345"},
+			want:   []rng{{0, 3}, {3, 8}, {8, 14}},
+		},
+		{
+			name:   "rejected write",
+			opts:   []Option{WithOverflowPolicy(OverflowReject)},
+			writes: []string{"abc", "0123456789", "d"},
+			want:   []rng{{0, 3}, {3, 3}, {3, 4}},
+		},
+		{
+			name:   "skipped by dedup",
+			opts:   []Option{WithDedup()},
+			writes: []string{"abc", "abc", "d"},
+			want:   []rng{{0, 3}, {3, 3}, {3, 4}},
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(0, 6, tt.opts...)
+
+			var got []rng
+			for _, w := range tt.writes {
+				start, end, _ := r.WriteTracked([]byte(w))
+				got = append(got, rng{start, end})
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("WriteTracked() got = %v, want %v", got, tt.want)
+			}
+
+			// the last range can be read back while retained
+			last := got[len(got)-1]
+			if b, err := r.OffsetBytes(last.start, int(last.end-last.start)); err != nil || string(b) != tt.writes[len(tt.writes)-1] {
+				t.Errorf("OffsetBytes() got = %q, %v, want %q", b, err, tt.writes[len(tt.writes)-1])
+			}
+		})
+	}
+}
+
+func TestRingBuffer_WriteExact(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		closed    bool
+		toWrite   string
+		expectLen int
+		wantErr   error
+		want      string
+	}{
+		{name: "matching", toWrite: "cd", expectLen: 2, want: "abcd"},
+		{name: "matching, empty", toWrite: "", expectLen: 0, want: "ab"},
+		{name: "shorter", toWrite: "cd", expectLen: 3, wantErr: io.ErrShortBuffer, want: "ab"},
+		{name: "longer", toWrite: "cde", expectLen: 2, wantErr: io.ErrShortBuffer, want: "ab"},
+		{name: "closed", closed: true, toWrite: "cd", expectLen: 2, wantErr: ErrClosed, want: ""},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(0, 8)
+			_, _ = r.Write([]byte("ab"))
+			if tt.closed {
+				_ = r.Close()
+			}
+
+			if err := r.WriteExact([]byte(tt.toWrite), tt.expectLen); err != tt.wantErr {
+				t.Errorf("WriteExact() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := r.String(); got != tt.want {
+				t.Errorf("String() got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_WriteStringChecked(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		opts        []Option
+		prefill     []string
+		drain       int
+		s           string
+		wantN       int
+		wantEvicted int
+		wantErr     error
+	}{
+		{
+			name:        "fitting",
+			prefill:     []string{"ab"},
+			s:           "cd",
+			wantN:       2,
+			wantEvicted: 0,
+		},
+		{
+			name:        "exactly filling",
+			prefill:     []string{"ab"},
+			s:           "cdef",
+			wantN:       4,
+			wantEvicted: 0,
+		},
+		{
+			name:        "entering ring mode",
+			prefill:     []string{"abcd"},
+			s:           "efgh",
+			wantN:       4,
+			wantEvicted: 2,
+		},
+		{
+			name:        "in ring mode",
+			prefill:     []string{"abcdef", "gh"},
+			s:           "ijk",
+			wantN:       3,
+			wantEvicted: 3,
+		},
+		{
+			name:        "larger than maxSize",
+			prefill:     []string{"abc"},
+			s:           "0123456789",
+			wantN:       10,
+			wantEvicted: 3,
+		},
+		{
+			name:        "drained before",
+			prefill:     []string{"abcdef"},
+			drain:       2,
+			s:           "gh",
+			wantN:       2,
+			wantEvicted: 0,
+		},
+		{
+			name:        "rejected",
+			opts:        []Option{WithOverflowPolicy(OverflowReject)},
+			prefill:     []string{"abc"},
+			s:           "0123456789",
+			wantN:       0,
+			wantEvicted: 0,
+			wantErr:     ErrRecordTooLarge,
+		},
+		{
+			name:        "skipped by dedup",
+			opts:        []Option{WithDedup()},
+			prefill:     []string{"abcdef"},
+			s:           "abcdef",
+			wantN:       6,
+			wantEvicted: 0,
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(0, 6, tt.opts...)
+			for _, p := range tt.prefill {
+				_, _ = r.Write([]byte(p))
+			}
+			r.Drain(tt.drain)
+
+			n, evicted, err := r.WriteStringChecked(tt.s)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("WriteStringChecked() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if n != tt.wantN || evicted != tt.wantEvicted {
+				t.Errorf("WriteStringChecked() got = %d, %d, want %d, %d", n, evicted, tt.wantN, tt.wantEvicted)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_Reset_noStaleBytes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		before string
+		after  string
+		grow   int
+	}{
+		{
+			name:   "non-ring mode",
+			before: "abcdef",
+			after:  "xy",
+		},
+		{
+			name:   "ring mode",
+			before: "abcdefghij",
+			after:  "xyz",
+		},
+		{
+			name:   "grow after reset",
+			before: "abc",
+			after:  "x",
+			grow:   8,
+		},
+		{
+			name:   "same content",
+			before: "abc",
+			after:  "abc",
+		},
+		{
+			name:   "nothing after reset",
+			before: "abcdefghij",
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(0, 8, WithDedup(), WithStringCache())
+			_, _ = r.Write([]byte(tt.before))
+			_ = r.String()
+			_ = r.Consume()
+
+			r.Reset()
+			if err := r.Grow(tt.grow); err != nil {
+				t.Fatalf("Grow() error = %v", err)
+			}
+			_, _ = r.Write([]byte(tt.after))
+
+			if got := string(r.Bytes()); got != tt.after {
+				t.Errorf("Bytes() got = %q, want %q", got, tt.after)
+			}
+			if got := r.String(); got != tt.after {
+				t.Errorf("String() got = %q, want %q", got, tt.after)
+			}
+			if got := string(r.Consume()); got != tt.after {
+				t.Errorf("Consume() got = %q, want %q", got, tt.after)
+			}
+			if got := r.Len(); got != len(tt.after) {
+				t.Errorf("Len() got = %d, want %d", got, len(tt.after))
+			}
+			if got, _ := r.OffsetBytes(0, len(tt.after)); string(got) != tt.after {
+				t.Errorf("OffsetBytes() got = %q, want %q", got, tt.after)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_Reset_partialLine(t *testing.T) {
+	t.Parallel()
+
+	var lines []string
+	r := NewRingBuffer(0, 16)
+	r.OnLine(func(line []byte) {
+		lines = append(lines, string(line))
+	})
+
+	_, _ = r.Write([]byte("old"))
+	r.Reset()
+	_, _ = r.Write([]byte("new\n"))
+
+	want := []string{"new"}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("OnLine() got = %q, want %q", lines, want)
+	}
+}
+
+func TestRingBuffer_Read(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		inputBuffer *RingBuffer
+		size        int
+		want        []byte
+		wantErr     error
+		wantString  string
+	}{
+		{
+			name:        "empty",
+			inputBuffer: NewRingBuffer(0, 4),
+			size:        2,
+			want:        []byte{},
+			wantErr:     io.EOF,
+			wantString:  "",
+		},
+		{
+			name: "zero length",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', 'c', 0},
+				pos:      3,
+				written:  3,
+				ringMode: false,
+				maxSize:  4,
+			},
+			size:       0,
+			want:       []byte{},
+			wantString: "abc",
+		},
+		{
+			name: "no ring, partial",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', 'c', 0},
+				pos:      3,
+				written:  3,
+				ringMode: false,
+				maxSize:  4,
+			},
+			size:       2,
+			want:       []byte("ab"),
+			wantString: "c",
+		},
+		{
+			name: "ring mode, across the wrap",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', '1', '2', '3', 'f', 'g'},
+				pos:      5,
+				written:  17,
+				ringMode: true,
+				maxSize:  7,
+			},
+			size:       4,
+			want:       []byte("fgab"),
+			wantString: "123",
+		},
+		{
+			name: "ring mode, more than length",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'e', 'b', 'c', 'd'},
+				pos:      1,
+				written:  5,
+				ringMode: true,
+				maxSize:  4,
+			},
+			size:       10,
+			want:       []byte("bcde"),
+			wantString: "",
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := make([]byte, tt.size)
+			n, err := tt.inputBuffer.Read(p)
+
+			if err != tt.wantErr {
+				t.Errorf("Read() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(p[:n], tt.want) {
+				t.Errorf("Read() got = %q, want %q", p[:n], tt.want)
+			}
+
+			if got := tt.inputBuffer.String(); got != tt.wantString {
+				t.Errorf("String() got = %q, want %q", got, tt.wantString)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_ReadGap(t *testing.T) {
+	t.Parallel()
+
+	type step struct {
+		write    string
+		drain    int
+		read     int
+		want     string
+		wantGap  int64
+		wantNext int64
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "no eviction",
+			steps: []step{
+				{write: "abcd", read: 2, want: "ab", wantNext: 2},
+				{write: "ef", read: 10, want: "cdef", wantNext: 6},
+			},
+		},
+		{
+			name: "burst evicts past the cursor",
+			steps: []step{
+				{write: "abcd", read: 2, want: "ab", wantNext: 2},
+				{write: "0123456789", read: 3, want: "234", wantGap: 4, wantNext: 9},
+				{read: 10, want: "56789", wantNext: 14},
+			},
+		},
+		{
+			name: "evicted before the first read",
+			steps: []step{
+				{write: "abcdefghijkl", read: 10, want: "efghijkl", wantGap: 4, wantNext: 12},
+			},
+		},
+		{
+			name: "drain keeps the gap",
+			steps: []step{
+				{write: "0123456789", drain: 2, read: 2, want: "45", wantGap: 2, wantNext: 6},
+			},
+		},
+		{
+			name: "gap reported once the buffer is empty",
+			steps: []step{
+				{write: "0123456789", read: 8, want: "23456789", wantGap: 2, wantNext: 10},
+				{write: "abcdefghijk", drain: 8, read: 10, want: "", wantGap: 3, wantNext: 21},
+			},
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(0, 8)
+			for i, s := range tt.steps {
+				_, _ = r.Write([]byte(s.write))
+				r.Drain(s.drain)
+
+				p := make([]byte, s.read)
+				n, _ := r.Read(p)
+
+				if got := string(p[:n]); got != s.want {
+					t.Errorf("step %d: Read() got = %q, want %q", i, got, s.want)
+				}
+				if got := r.ReadGap(); got != s.wantGap {
+					t.Errorf("step %d: ReadGap() got = %d, want %d", i, got, s.wantGap)
+				}
+				if r.readNext != s.wantNext {
+					t.Errorf("step %d: readNext got = %d, want %d", i, r.readNext, s.wantNext)
+				}
+			}
+		})
+	}
+}
+
+func TestRingBuffer_ReadGap_reset(t *testing.T) {
+	t.Parallel()
+
+	r := NewRingBuffer(0, 4)
+	_, _ = r.Write([]byte("abcdef"))
+	r.ResetStats()
+
+	p := make([]byte, 4)
+	if n, _ := r.Read(p); string(p[:n]) != "cdef" || r.ReadGap() != 0 {
+		t.Errorf("Read() after ResetStats got = %q, gap %d, want %q, gap 0", p[:n], r.ReadGap(), "cdef")
+	}
+
+	_, _ = r.Write([]byte("abcdef"))
+	r.Reset()
+	_, _ = r.Write([]byte("gh"))
+	if n, _ := r.Read(p); string(p[:n]) != "gh" || r.ReadGap() != 0 {
+		t.Errorf("Read() after Reset got = %q, gap %d, want %q, gap 0", p[:n], r.ReadGap(), "gh")
+	}
+}
+
+func TestRingBuffer_ReadGap_discarded(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		discard func(r *RingBuffer)
+		write   string
+		want    string
+	}{
+		{
+			name:    "ClearContent",
+			discard: func(r *RingBuffer) { r.ClearContent() },
+			write:   "xy",
+			want:    "xy",
+		},
+		{
+			name:    "ReplaceRange",
+			discard: func(r *RingBuffer) { _ = r.ReplaceRange(0, 2, nil) },
+			write:   "xy",
+			want:    "cxy",
+		},
+		{
+			name:    "SetMaxSize",
+			discard: func(r *RingBuffer) { _ = r.SetMaxSize(2) },
+			want:    "bc",
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(0, 8)
+			_, _ = r.Write([]byte("abc"))
+			tt.discard(r)
+			_, _ = r.Write([]byte(tt.write))
+
+			p := make([]byte, 8)
+			n, _ := r.Read(p)
+			if got := string(p[:n]); got != tt.want {
+				t.Errorf("Read() got = %q, want %q", got, tt.want)
+			}
+			if got := r.ReadGap(); got != 0 {
+				t.Errorf("ReadGap() got = %d, want 0", got)
+			}
+		})
+	}
+}
+
+// errReader returns its content and then err.
+type errReader struct {
+	content []byte
+	err     error
+}
+
+func (e *errReader) Read(p []byte) (int, error) {
+	if len(e.content) == 0 {
+		return 0, e.err
+	}
+	n := copy(p, e.content)
+	e.content = e.content[n:]
+	return n, nil
+}
+
+func TestRingBuffer_ReadFrom(t *testing.T) {
+	t.Parallel()
+
+	errTest := errors.New("test error")
+
+	tests := []struct {
+		name        string
+		inputBuffer *RingBuffer
+		src         io.Reader
+		wantN       int64
+		wantErr     error
+		wantString  string
+	}{
+		{
+			name:        "no overflow",
+			inputBuffer: NewRingBuffer(0, 10),
+			src:         bytes.NewReader([]byte("abcdef")),
+			wantN:       6,
+			wantString:  "abcdef",
+		},
+		{
+			name:        "overflow",
+			inputBuffer: NewRingBuffer(0, 4),
+			src:         bytes.NewReader([]byte("abcdef")),
+			wantN:       6,
+			wantString:  "cdef",
+		},
+		{
+			name:        "larger than a chunk",
+			inputBuffer: NewRingBuffer(0, 4),
+			src:         bytes.NewReader(append(make([]byte, readChunkSize), "abcdef"...)),
+			wantN:       readChunkSize + 6,
+			wantString:  "cdef",
+		},
+		{
+			name:        "reader error",
+			inputBuffer: NewRingBuffer(0, 10),
+			src:         &errReader{content: []byte("abc"), err: errTest},
+			wantN:       3,
+			wantErr:     errTest,
+			wantString:  "abc",
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			gotN, err := tt.inputBuffer.ReadFrom(tt.src)
+
+			if err != tt.wantErr {
+				t.Errorf("ReadFrom() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if gotN != tt.wantN {
+				t.Errorf("ReadFrom() got = %d, want %d", gotN, tt.wantN)
+			}
+
+			if got := tt.inputBuffer.String(); got != tt.wantString {
+				t.Errorf("String() got = %q, want %q", got, tt.wantString)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_ReadFromConn(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		maxSize     int
+		timeout     time.Duration
+		writes      []string
+		delay       time.Duration
+		stall       bool
+		wantN       int64
+		wantTimeout bool
+		wantString  string
+	}{
+		{
+			name:       "closed by the writer",
+			maxSize:    10,
+			timeout:    time.Second,
+			writes:     []string{"abc", "def"},
+			wantN:      6,
+			wantString: "abcdef",
+		},
+		{
+			name:       "slow writer within the timeout",
+			maxSize:    4,
+			timeout:    time.Second,
+			writes:     []string{"abc", "def"},
+			delay:      20 * time.Millisecond,
+			wantN:      6,
+			wantString: "cdef",
+		},
+		{
+			name:        "stalled writer",
+			maxSize:     10,
+			timeout:     50 * time.Millisecond,
+			writes:      []string{"abc", "def"},
+			stall:       true,
+			wantN:       6,
+			wantTimeout: true,
+			wantString:  "abcdef",
+		},
+		{
+			name:       "no timeout",
+			maxSize:    10,
+			writes:     []string{"abc"},
+			wantN:      3,
+			wantString: "abc",
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			client, server := net.Pipe()
+			defer client.Close()
+			defer server.Close()
+
+			go func() {
+				for _, w := range tt.writes {
+					time.Sleep(tt.delay)
+					_, _ = server.Write([]byte(w))
+				}
+				if !tt.stall {
+					_ = server.Close()
+				}
+			}()
+
+			r := NewRingBuffer(0, tt.maxSize)
+			gotN, err := r.ReadFromConn(client, tt.timeout)
+
+			if tt.wantTimeout {
+				if nErr, ok := err.(net.Error); !ok || !nErr.Timeout() {
+					t.Errorf("ReadFromConn() error = %v, want a timeout", err)
+				}
+			} else if err != nil {
+				t.Errorf("ReadFromConn() error = %v, want nil", err)
+			}
+
+			if gotN != tt.wantN {
+				t.Errorf("ReadFromConn() got = %d, want %d", gotN, tt.wantN)
+			}
+
+			if got := r.String(); got != tt.wantString {
+				t.Errorf("String() got = %q, want %q", got, tt.wantString)
+			}
+
+			// the expired deadline has been cleared
+			if tt.stall {
+				go func() { _, _ = server.Write([]byte("x")) }()
+				if _, err := client.Read(make([]byte, 1)); err != nil {
+					t.Errorf("Read() after ReadFromConn() error = %v, want nil", err)
+				}
+			}
+		})
+	}
+}
+
+// limitedWriter accepts at most n bytes, then fails with err.
+type limitedWriter struct {
+	bytes.Buffer
+	n   int
+	err error
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if len(p) > l.n {
+		m, _ := l.Buffer.Write(p[:l.n])
+		l.n = 0
+		return m, l.err
+	}
+	l.n -= len(p)
+	return l.Buffer.Write(p)
+}
+
+func TestRingBuffer_WriteTo(t *testing.T) {
+	t.Parallel()
+
+	errTest := errors.New("test error")
+
+	wrapped := func() *RingBuffer {
+		return &RingBuffer{
+			buf:      []byte{'a', 'b', '1', '2', '3', 'f', 'g'},
+			pos:      5,
+			written:  17,
+			ringMode: true,
+			maxSize:  7,
+		}
+	}
+
+	tests := []struct {
+		name        string
+		inputBuffer *RingBuffer
+		limit       int
+		wantN       int64
+		wantErr     error
+		wantOut     string
+		wantString  string
+	}{
+		{
+			name:        "empty",
+			inputBuffer: NewRingBuffer(0, 4),
+			limit:       10,
+			wantN:       0,
+			wantOut:     "",
+			wantString:  "",
+		},
+		{
+			name:        "wrapped",
+			inputBuffer: wrapped(),
+			limit:       10,
+			wantN:       7,
+			wantOut:     "fgab123",
+			wantString:  "",
+		},
+		{
+			name:        "writer error",
+			inputBuffer: wrapped(),
+			limit:       3,
+			wantN:       3,
+			wantErr:     errTest,
+			wantOut:     "fga",
+			wantString:  "b123",
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := &limitedWriter{n: tt.limit, err: errTest}
+			gotN, err := tt.inputBuffer.WriteTo(w)
+
+			if err != tt.wantErr {
+				t.Errorf("WriteTo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if gotN != tt.wantN {
+				t.Errorf("WriteTo() got = %d, want %d", gotN, tt.wantN)
+			}
+
+			if got := w.String(); got != tt.wantOut {
+				t.Errorf("WriteTo() wrote = %q, want %q", got, tt.wantOut)
+			}
+
+			if got := tt.inputBuffer.String(); got != tt.wantString {
+				t.Errorf("String() got = %q, want %q", got, tt.wantString)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_CopyTo(t *testing.T) {
+	t.Parallel()
+
+	errTest := errors.New("test error")
+
+	tests := []struct {
+		name    string
+		limit   int
+		consume bool
+		wantN   int64
+		wantErr error
+		wantOut string
+		wantLen int
+	}{
+		{
+			name:    "copy",
+			limit:   10,
+			wantN:   7,
+			wantOut: "fgab123",
+			wantLen: 7,
+		},
+		{
+			name:    "copy, writer error",
+			limit:   3,
+			wantN:   3,
+			wantErr: errTest,
+			wantOut: "fga",
+			wantLen: 7,
+		},
+		{
+			name:    "consume",
+			limit:   10,
+			consume: true,
+			wantN:   7,
+			wantOut: "fgab123",
+			wantLen: 0,
+		},
+		{
+			name:    "consume, writer error",
+			limit:   3,
+			consume: true,
+			wantN:   3,
+			wantErr: errTest,
+			wantOut: "fga",
+			wantLen: 4,
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := &RingBuffer{
+				buf:      []byte{'a', 'b', '1', '2', '3', 'f', 'g'},
+				pos:      5,
+				written:  17,
+				ringMode: true,
+				maxSize:  7,
+			}
+
+			w := &limitedWriter{n: tt.limit, err: errTest}
+			var (
+				gotN int64
+				err  error
+			)
+			if tt.consume {
+				gotN, err = r.WriteToConsume(w)
+			} else {
+				gotN, err = r.CopyTo(w)
+			}
+
+			if err != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if gotN != tt.wantN {
+				t.Errorf("got = %d, want %d", gotN, tt.wantN)
+			}
+			if got := w.String(); got != tt.wantOut {
+				t.Errorf("wrote = %q, want %q", got, tt.wantOut)
+			}
+			if got := r.Len(); got != tt.wantLen {
+				t.Errorf("Len() got = %d, want %d", got, tt.wantLen)
+			}
+			if got := r.Written(); got != 17 {
+				t.Errorf("Written() got = %d, want 17", got)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_ReadFromAt(t *testing.T) {
+	t.Parallel()
+
+	source := []byte("0123456789abcdef")
+
+	tests := []struct {
+		name        string
+		inputBuffer *RingBuffer
+		off         int64
+		n           int64
+		wantN       int64
+		wantErr     error
+		wantString  string
+		wantWritten int
+	}{
+		{
+			name:        "middle region, no overflow",
+			inputBuffer: NewRingBuffer(0, 10),
+			off:         4,
+			n:           6,
+			wantN:       6,
+			wantString:  "456789",
+			wantWritten: 6,
+		},
+		{
+			name:        "middle region, overflow",
+			inputBuffer: NewRingBuffer(0, 4),
+			off:         4,
+			n:           6,
+			wantN:       6,
+			wantString:  "6789",
+			wantWritten: 6,
+		},
+		{
+			name:        "up to the end of the source",
+			inputBuffer: NewRingBuffer(0, 10),
+			off:         12,
+			n:           4,
+			wantN:       4,
+			wantString:  "cdef",
+			wantWritten: 4,
+		},
+		{
+			name:        "past the end of the source",
+			inputBuffer: NewRingBuffer(0, 10),
+			off:         12,
+			n:           10,
+			wantN:       4,
+			wantErr:     io.EOF,
+			wantString:  "cdef",
+			wantWritten: 4,
+		},
+		{
+			name:        "zero bytes",
+			inputBuffer: NewRingBuffer(0, 10),
+			off:         4,
+			n:           0,
+			wantN:       0,
+			wantString:  "",
+			wantWritten: 0,
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			gotN, err := tt.inputBuffer.ReadFromAt(bytes.NewReader(source), tt.off, tt.n)
+
+			if err != tt.wantErr {
+				t.Errorf("ReadFromAt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if gotN != tt.wantN {
+				t.Errorf("ReadFromAt() got = %d, want %d", gotN, tt.wantN)
+			}
+
+			if got := tt.inputBuffer.String(); got != tt.wantString {
+				t.Errorf("String() got = %q, want %q", got, tt.wantString)
+			}
+
+			if got := tt.inputBuffer.Written(); got != tt.wantWritten {
+				t.Errorf("Written() got = %d, want %d", got, tt.wantWritten)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_Overhead(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		initialSize int
+		writes      []string
+		read        int
+		want        float64
+	}{
+		{name: "empty", initialSize: 0, want: 0},
+		{name: "empty, preallocated", initialSize: 8, want: 0},
+		{name: "preallocated", initialSize: 8, writes: []string{"ab"}, want: 4},
+		{name: "grown once", writes: []string{"a", "bc"}, want: 4.0 / 3},
+		{name: "grown twice", writes: []string{"a", "bc", "defg"}, want: 8.0 / 7},
+		{name: "exactly filled", writes: []string{"a", "bc", "defg", "hijklmnop"}, want: 1},
+		{name: "drained", writes: []string{"abcdefgh"}, read: 5, want: 8.0 / 3},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(tt.initialSize, 64)
+			for _, w := range tt.writes {
+				_, _ = r.Write([]byte(w))
+			}
+			_, _ = r.Read(make([]byte, tt.read))
+
+			if got := r.Overhead(); got != tt.want {
+				t.Errorf("Overhead() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_Len(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		inputBuffer *RingBuffer
+		want        int
+	}{
+		{
+			name:        "empty",
+			inputBuffer: NewRingBuffer(0, 4),
+			want:        0,
+		},
+		{
+			name: "no ring",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', 'c', 0},
+				pos:      3,
+				written:  3,
+				ringMode: false,
+				maxSize:  4,
+			},
+			want: 3,
+		},
+		{
+			name: "ring mode",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'e', 'b', 'c', 'd'},
+				pos:      1,
+				written:  5,
+				ringMode: true,
+				maxSize:  4,
+			},
+			want: 4,
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.inputBuffer.Len(); got != tt.want {
+				t.Errorf("Len() got = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_WouldEvict(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		writes []string
+		drain  int
+		k      int
+		want   bool
+	}{
+		{name: "empty", k: 8, want: false},
+		{name: "empty, larger than max", k: 9, want: true},
+		{name: "just below the threshold", writes: []string{"abcde"}, k: 3, want: false},
+		{name: "just above the threshold", writes: []string{"abcde"}, k: 4, want: true},
+		{name: "full, no ring", writes: []string{"abcdefgh"}, k: 1, want: true},
+		{name: "full, zero bytes", writes: []string{"abcdefgh"}, k: 0, want: false},
+		{name: "ring mode", writes: []string{"abcdefgh", "ij"}, k: 1, want: true},
+		{name: "ring mode, zero bytes", writes: []string{"abcdefgh", "ij"}, k: 0, want: false},
+		{name: "ring mode, drained", writes: []string{"abcdefgh", "ij"}, drain: 3, k: 3, want: false},
+		{name: "negative", writes: []string{"abcdefgh"}, k: -1, want: false},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(0, 8)
+			for _, w := range tt.writes {
+				_, _ = r.Write([]byte(w))
+			}
+			r.Drain(tt.drain)
+
+			if got := r.WouldEvict(tt.k); got != tt.want {
+				t.Errorf("WouldEvict() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_PrepareWrite(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		writes       []string
+		size         int
+		frozen       bool
+		wantEvict    bool
+		wantCap      int
+		wantErr      error
+		wantNoAllocs bool
+	}{
+		{name: "fits after growth", writes: []string{"ab"}, size: 5, wantEvict: false, wantCap: 8},
+		{name: "fits exactly", writes: []string{"ab"}, size: 14, wantEvict: false, wantCap: 16},
+		{name: "evicts when near full", writes: []string{"abcdefghijkl"}, size: 5, wantEvict: true, wantCap: 16},
+		{name: "larger than the maximum size", size: 20, wantEvict: true, wantCap: 16},
+		{name: "ring mode", writes: []string{"abcdefghijklmnopq"}, size: 1, wantEvict: true, wantCap: 16},
+		{name: "zero size", writes: []string{"ab"}, size: 0, wantEvict: false, wantCap: 2},
+		{name: "frozen", writes: []string{"ab"}, size: 5, frozen: true, wantCap: 2, wantErr: ErrFrozen},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(0, 16)
+			for _, w := range tt.writes {
+				_, _ = r.Write([]byte(w))
+			}
+			if tt.frozen {
+				r.Freeze()
+			}
+
+			got, err := r.PrepareWrite(tt.size)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("PrepareWrite() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.wantEvict {
+				t.Errorf("PrepareWrite() got = %v, want %v", got, tt.wantEvict)
+			}
+			if r.Cap() != tt.wantCap {
+				t.Errorf("Cap() got = %d, want %d", r.Cap(), tt.wantCap)
+			}
+
+			// the prepared write doesn't grow the buffer anymore
+			_, _ = r.Write(make([]byte, tt.size))
+			if tt.wantErr == nil && r.Cap() != tt.wantCap {
+				t.Errorf("Cap() after Write got = %d, want %d", r.Cap(), tt.wantCap)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_Drain(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		inputBuffer *RingBuffer
+		n           int
+		want        int
+		wantBuffer  *RingBuffer
+	}{
+		{
+			name: "no ring, within length",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', 'c', 'd', 0, 0},
+				pos:      4,
+				written:  4,
+				ringMode: false,
+				maxSize:  7,
+			},
+			n:    1,
+			want: 1,
+			wantBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', 'c', 'd', 0, 0},
+				off:      1,
+				pos:      4,
+				written:  4,
+				ringMode: false,
+				maxSize:  7,
+				readNext: 1,
+				midLine:  true,
+			},
+		},
+		{
+			name: "no ring, exactly the length",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', 'c', 'd', 0, 0},
+				pos:      4,
+				written:  4,
+				ringMode: false,
+				maxSize:  7,
+			},
+			n:    4,
+			want: 4,
+			wantBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', 'c', 'd', 0, 0},
+				pos:      0,
+				written:  4,
+				ringMode: false,
+				maxSize:  7,
+				readNext: 4,
+				midLine:  true,
+			},
+		},
+		{
+			name: "no ring, beyond the length",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', 'c', 'd', 0, 0},
+				pos:      4,
+				written:  4,
+				ringMode: false,
+				maxSize:  7,
+			},
+			n:    10,
+			want: 4,
+			wantBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', 'c', 'd', 0, 0},
+				pos:      0,
+				written:  4,
+				ringMode: false,
+				maxSize:  7,
+				readNext: 4,
+				midLine:  true,
+			},
+		},
+		{
+			name: "ring mode, within length",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', '1', '2', '3', 'f', 'g'},
+				pos:      5,
+				written:  17,
+				ringMode: true,
+				maxSize:  7,
+			},
+			n:    3,
+			want: 3,
+			wantBuffer: &RingBuffer{
+				buf:      []byte{'f', 'g', 'a', 'b', '1', '2', '3'},
+				off:      3,
+				pos:      7,
+				written:  17,
+				ringMode: false,
+				maxSize:  7,
+				readNext: 3,
+				midLine:  true,
+			},
+		},
+		{
+			name: "ring mode, exactly the length",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', '1', '2', '3', 'f', 'g'},
+				pos:      5,
+				written:  17,
+				ringMode: true,
+				maxSize:  7,
+			},
+			n:    7,
+			want: 7,
+			wantBuffer: &RingBuffer{
+				buf:      []byte{'f', 'g', 'a', 'b', '1', '2', '3'},
+				pos:      0,
+				written:  17,
+				ringMode: false,
+				maxSize:  7,
+				readNext: 7,
+				midLine:  true,
+			},
+		},
+		{
+			name: "ring mode, beyond the length",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'e', 'b', 'c', 'd'},
+				pos:      1,
+				written:  5,
+				ringMode: true,
+				maxSize:  4,
+			},
+			n:    5,
+			want: 4,
+			wantBuffer: &RingBuffer{
+				buf:      []byte{'b', 'c', 'd', 'e'},
+				pos:      0,
+				written:  5,
+				ringMode: false,
+				maxSize:  4,
+				readNext: 4,
+				midLine:  true,
+			},
+		},
+		{
+			name: "negative",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'e', 'b', 'c', 'd'},
+				pos:      1,
+				written:  5,
+				ringMode: true,
+				maxSize:  4,
+			},
+			n:    -1,
+			want: 0,
+			wantBuffer: &RingBuffer{
+				buf:      []byte{'e', 'b', 'c', 'd'},
+				pos:      1,
+				written:  5,
+				ringMode: true,
+				maxSize:  4,
+			},
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := tt.inputBuffer.Drain(tt.n)
+
+			if got != tt.want {
+				t.Errorf("Drain() got = %d, want %d", got, tt.want)
+			}
+
+			if !reflect.DeepEqual(tt.inputBuffer, tt.wantBuffer) {
+				t.Errorf("Drain() got = %+v want %+v", tt.inputBuffer, tt.wantBuffer)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_Drain_thenWrite(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		drain      int
+		write      string
+		wantString string
+		wantOff    int
+		wantRing   bool
+	}{
+		{
+			name:       "fitting after the content",
+			drain:      2,
+			write:      "x",
+			wantString: "cdex",
+			wantOff:    2,
+		},
+		{
+			name:       "using the room of the drained bytes",
+			drain:      2,
+			write:      "xyz",
+			wantString: "cdexyz",
+			wantOff:    0,
+		},
+		{
+			name:       "overwriting",
+			drain:      2,
+			write:      "wxyz",
+			wantString: "dewxyz",
+			wantOff:    0,
+			wantRing:   true,
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(6, 6)
+			_, _ = r.Write([]byte("abcde"))
+			r.Drain(tt.drain)
+
+			if _, err := r.Write([]byte(tt.write)); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			if got := r.String(); got != tt.wantString {
+				t.Errorf("String() got = %q, want %q", got, tt.wantString)
+			}
+			if r.off != tt.wantOff || r.ringMode != tt.wantRing {
+				t.Errorf("off, ringMode got = %d, %t, want %d, %t", r.off, r.ringMode, tt.wantOff, tt.wantRing)
+			}
+			if err := r.Validate(); err != nil {
+				t.Errorf("Validate() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_OldestOffset(t *testing.T) {
+	t.Parallel()
+
+	r := NewRingBuffer(0, 4)
+
+	steps := []struct {
+		toWrite string
+		want    int64
+	}{
+		{toWrite: "", want: 0},
+		{toWrite: "ab", want: 0},
+		{toWrite: "cd", want: 0},
+		{toWrite: "e", want: 1},
+		{toWrite: "fgh", want: 4},
+		{toWrite: "ijklmn", want: 10},
+	}
+	for _, s := range steps {
+		_, _ = r.Write([]byte(s.toWrite))
+
+		if got := r.OldestOffset(); got != s.want {
+			t.Errorf("OldestOffset() after writing %q got = %d, want %d", s.toWrite, got, s.want)
+		}
+	}
+}
+
+func TestRingBuffer_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		inputBuffer *RingBuffer
+		wantErr     bool
+	}{
+		{
+			name:        "new buffer",
+			inputBuffer: NewRingBuffer(3, 7),
+			wantErr:     false,
+		},
+		{
+			name: "ring mode",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'e', 'b', 'c', 'd'},
+				pos:      1,
+				written:  5,
+				ringMode: true,
+				maxSize:  4,
+			},
+			wantErr: false,
+		},
+		{
+			name: "buffer longer than maxSize",
+			inputBuffer: &RingBuffer{
+				buf:     []byte{'a', 'b', 'c', 'd'},
+				maxSize: 3,
+			},
+			wantErr: true,
+		},
+		{
+			name: "pos out of range",
+			inputBuffer: &RingBuffer{
+				buf:     []byte{'a', 'b', 'c'},
+				pos:     4,
+				written: 4,
+				maxSize: 7,
+			},
+			wantErr: true,
+		},
+		{
+			name: "ring mode on a partial buffer",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', 'c'},
+				pos:      1,
+				written:  8,
+				ringMode: true,
+				maxSize:  7,
+			},
+			wantErr: true,
+		},
+		{
+			name: "written lower than length",
+			inputBuffer: &RingBuffer{
+				buf:     []byte{'a', 'b', 'c'},
+				pos:     3,
+				written: 2,
+				maxSize: 7,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tt.inputBuffer.Validate()
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// FuzzRingBuffer_Write splits data in writes of arbitrary length, checking the
+// buffer invariants after each of them.
+// Every byte of data starting a write is used as the length of that write.
+func FuzzRingBuffer_Write(f *testing.F) {
+	f.Add(uint8(0), uint8(1), []byte("\x01a"))
+	f.Add(uint8(3), uint8(7), []byte("\x05abcde\x02fg\x0chijklmnopqrs"))
+	f.Add(uint8(0), uint8(0), []byte("\x03abc"))
+
+	f.Fuzz(func(t *testing.T, initialSize, maxSize uint8, data []byte) {
+		r := NewRingBuffer(int(initialSize), int(maxSize))
+
+		for len(data) > 0 {
+			n := int(data[0])
+			data = data[1:]
+			if n > len(data) {
+				n = len(data)
+			}
+
+			if _, err := r.Write(data[:n]); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			data = data[n:]
+
+			if err := r.Validate(); err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			assertRingModeInvariant(t, r)
+		}
+	})
+}
+
+// assertRingModeInvariant checks that a buffer that has only been written
+// is in ring mode if and only if some content has been overwritten.
+func assertRingModeInvariant(t *testing.T, r *RingBuffer) {
+	t.Helper()
+
+	if want := r.Written() > r.MaxSize(); r.ringMode != want {
+		t.Fatalf("ringMode = %v with written %d and maxSize %d, want %v", r.ringMode, r.Written(), r.MaxSize(), want)
+	}
+}
+
+func FuzzRingBuffer_ringModeInvariant(f *testing.F) {
+	f.Add(uint8(4), []byte("\x00\x04abcd\x01\x01e"))
+	f.Add(uint8(3), []byte("\x02\x02ab\x03\x05cdefg\x00\x01h"))
+	f.Add(uint8(0), []byte("\x01\x01a"))
+	f.Add(uint8(4), []byte("\x02\x06abcdef"))
+
+	f.Fuzz(func(t *testing.T, maxSize uint8, data []byte) {
+		r := NewRingBuffer(0, int(maxSize))
+
+		// every step is an operation byte, a length byte and the bytes
+		// to write
+		for len(data) >= 2 {
+			op, n := data[0], int(data[1])
+			data = data[2:]
+			if n > len(data) {
+				n = len(data)
+			}
+			p := data[:n]
+			data = data[n:]
+
+			var err error
+			switch op % 4 {
+			case 0:
+				_, err = r.Write(p)
+			case 1:
+				_, err = r.WriteWith(len(p), func(dst []byte) int { return copy(dst, p) })
+			case 2:
+				_, err = r.WriteMulti(p[:len(p)/2], p[len(p)/2:])
+			case 3:
+				for _, c := range p {
+					if err = r.WriteByte(c); err != nil {
+						break
+					}
+				}
+			}
+			if err != nil {
+				t.Fatalf("write error = %v", err)
+			}
+
+			assertRingModeInvariant(t, r)
+		}
+	})
+}
+
+// FuzzRingBuffer_Bytes checks the content after every write against a
+// reference model: a plain slice keeping the last maxSize bytes written.
+func FuzzRingBuffer_Bytes(f *testing.F) {
+	f.Add(uint8(0), uint8(1), []byte("\x01a"))
+	f.Add(uint8(3), uint8(7), []byte("\x05abcde\x02fg\x0chijklmnopqrs"))
+	f.Add(uint8(2), uint8(4), []byte("\x03abc\x01d\x04efgh\x00\x02ij"))
+
+	f.Fuzz(func(t *testing.T, initialSize, maxSize uint8, data []byte) {
+		r := NewRingBuffer(int(initialSize), int(maxSize))
+
+		var model []byte
+		for len(data) > 0 {
+			n := int(data[0])
+			data = data[1:]
+			if n > len(data) {
+				n = len(data)
+			}
+
+			if _, err := r.Write(data[:n]); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+
+			model = append(model, data[:n]...)
+			if len(model) > int(maxSize) {
+				model = model[len(model)-int(maxSize):]
+			}
+			data = data[n:]
+
+			if got := r.Bytes(); !bytes.Equal(got, model) {
+				t.Fatalf("Bytes() got = %q, want %q", got, model)
+			}
+			if got := r.String(); got != string(model) {
+				t.Fatalf("String() got = %q, want %q", got, model)
+			}
+		}
+	})
+}
+
+func TestRingBuffer_Write_exactlyMaxSize(t *testing.T) {
+	t.Parallel()
+
+	const maxSize = 6
+
+	tests := []struct {
+		name        string
+		initialSize int
+		prefill     []string
+		drain       int
+	}{
+		{
+			name:        "from empty",
+			initialSize: 0,
+		},
+		{
+			name:        "from empty, pre-allocated",
+			initialSize: maxSize,
+		},
+		{
+			name:        "from half-full",
+			initialSize: 0,
+			prefill:     []string{"abc"},
+		},
+		{
+			name:        "from full, not wrapped",
+			initialSize: 0,
+			prefill:     []string{"abcdef"},
+		},
+		{
+			name:        "from wrapped",
+			initialSize: 0,
+			prefill:     []string{"abcdef", "gh"},
+		},
+		{
+			name:        "from wrapped, write position at the end",
+			initialSize: 0,
+			prefill:     []string{"abcdef", "gh", "ijkl"},
+		},
+		{
+			name:        "from drained",
+			initialSize: 0,
+			prefill:     []string{"abcdef", "gh"},
+			drain:       3,
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(tt.initialSize, maxSize)
+			written := 0
+			for _, p := range tt.prefill {
+				_, _ = r.Write([]byte(p))
+				written += len(p)
+			}
+			r.Drain(tt.drain)
+
+			for _, p := range []string{"012345", "6789AB"} {
+				if n, err := r.Write([]byte(p)); n != maxSize || err != nil {
+					t.Fatalf("Write() got = %d, %v, want %d, nil", n, err, maxSize)
+				}
+				written += len(p)
+
+				if got := string(r.Bytes()); got != p {
+					t.Errorf("Bytes() got = %q, want %q", got, p)
+				}
+				if got := r.String(); got != p {
+					t.Errorf("String() got = %q, want %q", got, p)
+				}
+				if got := r.Written(); got != written {
+					t.Errorf("Written() got = %d, want %d", got, written)
+				}
+				if err := r.Validate(); err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestRingBuffer_MaxSizeOne(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		initialSize int
+		writes      []string
+		want        string
+	}{
+		{
+			name:        "single byte",
+			initialSize: 0,
+			writes:      []string{"a"},
+			want:        "a",
+		},
+		{
+			name:        "one byte at a time",
+			initialSize: 0,
+			writes:      []string{"a", "b", "c", "d"},
+			want:        "d",
+		},
+		{
+			name:        "one byte at a time, pre-allocated",
+			initialSize: 1,
+			writes:      []string{"a", "b", "c"},
+			want:        "c",
+		},
+		{
+			name:        "bulk",
+			initialSize: 0,
+			writes:      []string{"abcdef"},
+			want:        "f",
+		},
+		{
+			name:        "bulk after ring mode",
+			initialSize: 5,
+			writes:      []string{"a", "bcd", "e", "fghij"},
+			want:        "j",
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(tt.initialSize, 1)
+
+			written := 0
+			for _, w := range tt.writes {
+				_, _ = r.Write([]byte(w))
+				written += len(w)
+
+				if got, want := r.String(), w[len(w)-1:]; got != want {
+					t.Errorf("String() after writing %q got = %q, want %q", w, got, want)
+				}
+				if err := r.Validate(); err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+			}
+
+			if got := r.String(); got != tt.want {
+				t.Errorf("String() got = %q, want %q", got, tt.want)
+			}
+			if got := r.Bytes(); string(got) != tt.want {
+				t.Errorf("Bytes() got = %q, want %q", got, tt.want)
+			}
+			if got := r.Written(); got != written {
+				t.Errorf("Written() got = %d, want %d", got, written)
+			}
+			if got := r.Cap(); got != 1 {
+				t.Errorf("Cap() got = %d, want 1", got)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_FillExactlyMaxSize(t *testing.T) {
+	t.Parallel()
+
+	r := NewRingBuffer(0, 4)
+	_, _ = r.Write([]byte("ab"))
+	_, _ = r.Write([]byte("cd"))
+
+	if r.ringMode {
+		t.Errorf("ringMode got = true after filling exactly maxSize, want false")
+	}
+	if got := r.String(); got != "abcd" {
+		t.Errorf("String() got = %q, want %q", got, "abcd")
+	}
+
+	_, _ = r.Write([]byte("e"))
+
+	if !r.ringMode {
+		t.Errorf("ringMode got = false after overwriting, want true")
+	}
+	if got := r.String(); got != "bcde" {
+		t.Errorf("String() got = %q, want %q", got, "bcde")
+	}
+}
+
+func TestRingBuffer_TakeBytes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		writes []string
+		want   string
+	}{
+		{
+			name: "empty",
+			want: "",
+		},
+		{
+			name:   "no ring",
+			writes: []string{"ab", "c"},
+			want:   "abc",
+		},
+		{
+			name:   "ring mode",
+			writes: []string{"abc", "defgh"},
+			want:   "cdefgh",
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(0, 6)
+			for _, w := range tt.writes {
+				_, _ = r.Write([]byte(w))
+			}
+
+			if got := string(r.TakeBytes()); got != tt.want {
+				t.Errorf("TakeBytes() got = %q, want %q", got, tt.want)
+			}
+			if got := r.Len(); got != 0 {
+				t.Errorf("Len() got = %d, want 0", got)
+			}
+
+			_, _ = r.Write([]byte("xy"))
+			if got := r.String(); got != "xy" {
+				t.Errorf("String() got = %q, want %q", got, "xy")
+			}
+		})
+	}
+}
+
+func TestRingBuffer_ResetZero(t *testing.T) {
+	t.Parallel()
+
+	r := NewRingBuffer(0, 4)
+	_, _ = r.Write([]byte("abcdef"))
+
+	r.ResetZero()
+
+	want := &RingBuffer{
+		buf:        []byte{0, 0, 0, 0},
+		pos:        0,
+		written:    0,
+		ringMode:   false,
+		maxSize:    4,
+		generation: 1,
+	}
+	if !reflect.DeepEqual(r, want) {
+		t.Errorf("ResetZero() got = %+v want %+v", r, want)
+	}
+}
+
+func TestRingBuffer_ResetFull(t *testing.T) {
+	t.Parallel()
+
+	r := NewRingBuffer(0, 4,
+		WithOverflowPolicy(OverflowReject),
+		WithLazyFull(),
+		WithMaxWriteSize(3),
+		WithWriteRate(time.Second),
+		WithLatencyTracking(),
+		WithDedup(),
+		WithStringCache(),
+		WithSourceOffsets(),
+	)
+	r.OnLine(func([]byte) {})
+	r.OnDrainBelow(2, func() {})
+	_, _ = r.Write([]byte("ab\n"))
+	_ = r.String()
+	r.Freeze()
+
+	if err := r.ResetFull(); err != nil {
+		t.Errorf("ResetFull() error = %v", err)
+	}
+
+	want := &RingBuffer{
+		buf:        []byte{'a', 'b', '\n', 0},
+		pos:        0,
+		written:    0,
+		ringMode:   false,
+		maxSize:    4,
+		generation: 1,
+	}
+	if !reflect.DeepEqual(r, want) {
+		t.Errorf("ResetFull() got = %+v want %+v", r, want)
+	}
+
+	if _, err := r.Write([]byte("abcdef")); err != nil {
+		t.Errorf("Write() after ResetFull error = %v", err)
+	}
+	if got := r.String(); got != "cdef" {
+		t.Errorf("String() after ResetFull got = %q, want %q", got, "cdef")
+	}
+}
+
+func TestRingBuffer_ClearContent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		inputBuffer *RingBuffer
+		wantBuffer  *RingBuffer
+	}{
+		{
+			name: "ok",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'e', 'b', 'c', 'd'},
+				pos:      1,
+				written:  5,
+				ringMode: true,
+				maxSize:  4,
+			},
+			wantBuffer: &RingBuffer{
+				buf:      []byte{'e', 'b', 'c', 'd'},
+				pos:      0,
+				written:  5,
+				ringMode: false,
+				maxSize:  4,
+				readNext: 4,
+				midLine:  true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tt.inputBuffer.ClearContent()
+
+			if !reflect.DeepEqual(tt.inputBuffer, tt.wantBuffer) {
+				t.Errorf("ClearContent() got = %+v want %+v", tt.inputBuffer, tt.wantBuffer)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_ResetStats(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		inputBuffer *RingBuffer
+		wantBuffer  *RingBuffer
+	}{
+		{
+			name: "no ring",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'b', 'c', 'd', 0},
+				pos:      3,
+				written:  9,
+				ringMode: false,
+				maxSize:  4,
+			},
+			wantBuffer: &RingBuffer{
+				buf:      []byte{'b', 'c', 'd', 0},
+				pos:      3,
+				written:  3,
+				ringMode: false,
+				maxSize:  4,
+			},
+		},
+		{
+			name: "ring mode",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'e', 'b', 'c', 'd'},
+				pos:      1,
+				written:  5,
+				ringMode: true,
+				maxSize:  4,
+			},
+			wantBuffer: &RingBuffer{
+				buf:      []byte{'e', 'b', 'c', 'd'},
+				pos:      1,
+				written:  4,
+				ringMode: true,
+				maxSize:  4,
+			},
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tt.inputBuffer.ResetStats()
+
+			if !reflect.DeepEqual(tt.inputBuffer, tt.wantBuffer) {
+				t.Errorf("ResetStats() got = %+v want %+v", tt.inputBuffer, tt.wantBuffer)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_Chunks(t *testing.T) {
+	t.Parallel()
+
+	wrapped := func() *RingBuffer {
+		return &RingBuffer{
+			buf:      []byte{'a', 'b', '1', '2', '3', 'f', 'g'},
+			pos:      5,
+			written:  17,
+			ringMode: true,
+			maxSize:  7,
+		}
+	}
+
+	tests := []struct {
+		name        string
+		inputBuffer *RingBuffer
+		size        int
+		want        [][]byte
+	}{
+		{
+			name:        "empty",
+			inputBuffer: NewRingBuffer(0, 7),
+			size:        2,
+			want:        nil,
+		},
+		{
+			name:        "invalid size",
+			inputBuffer: wrapped(),
+			size:        0,
+			want:        nil,
+		},
+		{
+			name:        "wrapped, not a multiple of size",
+			inputBuffer: wrapped(),
+			size:        3,
+			want:        [][]byte{[]byte("fga"), []byte("b12"), []byte("3")},
+		},
+		{
+			name: "wrapped, multiple of size",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'e', 'f', 'a', 'b', 'c', 'd'},
+				pos:      2,
+				written:  8,
+				ringMode: true,
+				maxSize:  6,
+			},
+			size: 2,
+			want: [][]byte{[]byte("ab"), []byte("cd"), []byte("ef")},
+		},
+		{
+			name:        "size greater than length",
+			inputBuffer: wrapped(),
+			size:        10,
+			want:        [][]byte{[]byte("fgab123")},
+		},
+		{
+			name:        "maximum size",
+			inputBuffer: wrapped(),
+			size:        maxInt,
+			want:        [][]byte{[]byte("fgab123")},
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := tt.inputBuffer.Chunks(tt.size)
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Chunks() got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_Swap(t *testing.T) {
+	t.Parallel()
+
+	a := &RingBuffer{
+		buf:      []byte{'e', 'b', 'c', 'd'},
+		pos:      1,
+		written:  5,
+		ringMode: true,
+		maxSize:  4,
+	}
+	b := &RingBuffer{
+		buf:      []byte{'x', 'y', 0},
+		pos:      2,
+		written:  2,
+		ringMode: false,
+		maxSize:  10,
+	}
+
+	wantA := *b
+	wantB := *a
+
+	a.Swap(b)
+
+	if !reflect.DeepEqual(*a, wantA) {
+		t.Errorf("Swap() got = %+v want %+v", *a, wantA)
+	}
+	if !reflect.DeepEqual(*b, wantB) {
+		t.Errorf("Swap() got = %+v want %+v", *b, wantB)
+	}
+	if got := a.String(); got != "xy" {
+		t.Errorf("String() got = %q, want %q", got, "xy")
+	}
+	if got := b.String(); got != "bcde" {
+		t.Errorf("String() got = %q, want %q", got, "bcde")
+	}
+}
+
+func TestRingBuffer_Retained(t *testing.T) {
+	t.Parallel()
+
+	// "fgab123" retained, from offset 10 to 16
+	r := &RingBuffer{
+		buf:      []byte{'a', 'b', '1', '2', '3', 'f', 'g'},
+		pos:      5,
+		written:  17,
+		ringMode: true,
+		maxSize:  7,
+	}
+
+	tests := []struct {
+		offset int64
+		want   bool
+	}{
+		{offset: -1, want: false},
+		{offset: 9, want: false},
+		{offset: 10, want: true},
+		{offset: 16, want: true},
+		{offset: 17, want: false},
+	}
+	for _, tt := range tests {
+		if got := r.Retained(tt.offset); got != tt.want {
+			t.Errorf("Retained(%d) got = %v, want %v", tt.offset, got, tt.want)
+		}
+	}
+}
+
+func TestRingBuffer_OffsetBytes(t *testing.T) {
+	t.Parallel()
+
+	// "fgab123" retained, from offset 10 to 16
+	r := &RingBuffer{
+		buf:      []byte{'a', 'b', '1', '2', '3', 'f', 'g'},
+		pos:      5,
+		written:  17,
+		ringMode: true,
+		maxSize:  7,
+	}
+
+	tests := []struct {
+		name    string
+		offset  int64
+		n       int
+		want    []byte
+		wantErr error
+	}{
+		{
+			name:    "evicted",
+			offset:  9,
+			n:       2,
+			wantErr: ErrEvicted,
+		},
+		{
+			name:   "first segment",
+			offset: 10,
+			n:      2,
+			want:   []byte("fg"),
+		},
+		{
+			name:   "across the wrap",
+			offset: 11,
+			n:      3,
+			want:   []byte("gab"),
+		},
+		{
+			name:   "second segment, up to the end",
+			offset: 13,
+			n:      4,
+			want:   []byte("b123"),
+		},
+		{
+			name:   "empty",
+			offset: 17,
+			n:      0,
+			want:   []byte{},
+		},
+		{
+			name:    "beyond written",
+			offset:  15,
+			n:       3,
+			wantErr: ErrOutOfRange,
+		},
+		{
+			name:    "after written",
+			offset:  20,
+			n:       1,
+			wantErr: ErrOutOfRange,
+		},
+		{
+			name:    "maximum offset",
+			offset:  1<<63 - 1,
+			n:       1,
+			wantErr: ErrOutOfRange,
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := r.OffsetBytes(tt.offset, tt.n)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("OffsetBytes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("OffsetBytes() got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_Consume(t *testing.T) {
+	t.Parallel()
+
+	type step struct {
+		toWrite string
+		want    string
+		wantGap int64
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "incremental",
+			steps: []step{
+				{toWrite: "ab", want: "ab", wantGap: 0},
+				{toWrite: "", want: "", wantGap: 0},
+				{toWrite: "cd", want: "cd", wantGap: 0},
+				{toWrite: "efg", want: "efg", wantGap: 0},
+			},
+		},
+		{
+			name: "eviction dropped unconsumed bytes",
+			steps: []step{
+				{toWrite: "ab", want: "ab", wantGap: 0},
+				{toWrite: "cdefghi", want: "defghi", wantGap: 1},
+				{toWrite: "jk", want: "jk", wantGap: 0},
+				{toWrite: "0123456789", want: "456789", wantGap: 4},
+			},
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(0, 6)
+			for _, s := range tt.steps {
+				_, _ = r.Write([]byte(s.toWrite))
+
+				if got := string(r.Consume()); got != s.want {
+					t.Errorf("Consume() after writing %q got = %q, want %q", s.toWrite, got, s.want)
+				}
+				if got := r.GapSinceLastConsume(); got != s.wantGap {
+					t.Errorf("GapSinceLastConsume() after writing %q got = %d, want %d", s.toWrite, got, s.wantGap)
+				}
+			}
+		})
+	}
+}
+
+func TestRingBuffer_Diff(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		before  string
+		after   string
+		reset   bool
+		want    string
+		wantErr error
+		wantMsg string
+	}{
+		{
+			name:   "nothing new",
+			before: "abc",
+			want:   "",
+		},
+		{
+			name:   "incremental",
+			before: "abc",
+			after:  "de",
+			want:   "de",
+		},
+		{
+			name:   "incremental in ring mode",
+			before: "abcdefgh",
+			after:  "ij",
+			want:   "ij",
+		},
+		{
+			name:    "eviction gap",
+			before:  "abc",
+			after:   "defghijkl",
+			want:    "efghijkl",
+			wantErr: ErrEvicted,
+			wantMsg: "1 bytes lost",
+		},
+		{
+			name:    "reset after the snapshot",
+			before:  "abc",
+			reset:   true,
+			after:   "d",
+			wantErr: ErrOutOfRange,
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(0, 8)
+			_, _ = r.Write([]byte(tt.before))
+
+			data, err := r.MarshalBinary()
+			if err != nil {
+				t.Fatalf("MarshalBinary() error = %v", err)
+			}
+			prev := &RingBuffer{}
+			if err := prev.UnmarshalBinary(data); err != nil {
+				t.Fatalf("UnmarshalBinary() error = %v", err)
+			}
+
+			if tt.reset {
+				r.Reset()
+			}
+			_, _ = r.Write([]byte(tt.after))
+
+			got, err := r.Diff(prev)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Diff() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Errorf("Diff() error = %q, want it to contain %q", err, tt.wantMsg)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Diff() got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_Since(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		before       string
+		after        string
+		read         int
+		reset        bool
+		want         string
+		wantRetained bool
+	}{
+		{
+			name:         "nothing new",
+			before:       "abc",
+			want:         "",
+			wantRetained: true,
+		},
+		{
+			name:         "incremental",
+			before:       "abc",
+			after:        "de",
+			want:         "de",
+			wantRetained: true,
+		},
+		{
+			name:         "incremental in ring mode",
+			before:       "abcdefgh",
+			after:        "ij",
+			want:         "ij",
+			wantRetained: true,
+		},
+		{
+			name:         "eviction gap",
+			before:       "abc",
+			after:        "defghijkl",
+			want:         "efghijkl",
+			wantRetained: false,
+		},
+		{
+			name:         "drained",
+			before:       "abc",
+			after:        "def",
+			read:         4,
+			want:         "ef",
+			wantRetained: false,
+		},
+		{
+			name:         "reset",
+			before:       "abc",
+			reset:        true,
+			after:        "d",
+			want:         "d",
+			wantRetained: false,
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(0, 8)
+			_, _ = r.Write([]byte(tt.before))
+
+			got, token, retained := r.Since(0)
+			if string(got) != tt.before || token != uint64(len(tt.before)) || !retained {
+				t.Fatalf("Since(0) got = %q, %d, %v, want %q, %d, true", got, token, retained, tt.before, len(tt.before))
+			}
+
+			if tt.reset {
+				r.Reset()
+			}
+			_, _ = r.Write([]byte(tt.after))
+			_, _ = r.Read(make([]byte, tt.read))
+
+			got, next, retained := r.Since(token)
+			if string(got) != tt.want {
+				t.Errorf("Since() got = %q, want %q", got, tt.want)
+			}
+			if next != uint64(r.Written()) {
+				t.Errorf("Since() token got = %d, want %d", next, r.Written())
+			}
+			if retained != tt.wantRetained {
+				t.Errorf("Since() retained got = %v, want %v", retained, tt.wantRetained)
+			}
+
+			// nothing new since the returned token
+			if got, _, retained := r.Since(next); len(got) != 0 || !retained {
+				t.Errorf("Since() with the returned token got = %q, %v, want empty, true", got, retained)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_allocationFailure(t *testing.T) {
+	t.Parallel()
+
+	// failAbove returns an allocator failing for sizes above limit
+	failAbove := func(limit int) func(n int) ([]byte, error) {
+		return func(n int) ([]byte, error) {
+			if n > limit {
+				return nil, fmt.Errorf("%w: allocation of %d bytes failed", ErrTooLarge, n)
+			}
+			return makeSlice(n)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		prefill string
+		do      func(r *RingBuffer) error
+	}{
+		{
+			name:    "write",
+			prefill: "ab",
+			do: func(r *RingBuffer) error {
+				_, err := r.Write([]byte("cdefg"))
+				return err
+			},
+		},
+		{
+			name:    "grow",
+			prefill: "abcd",
+			do: func(r *RingBuffer) error {
+				return r.Grow(10)
+			},
+		},
+		{
+			name:    "write into an empty buffer",
+			prefill: "",
+			do: func(r *RingBuffer) error {
+				_, err := r.Write([]byte("abcdefgh"))
+				return err
+			},
+		},
+		{
+			name:    "shrink",
+			prefill: "abcd",
+			do: func(r *RingBuffer) error {
+				r.alloc = failAbove(0)
+				return r.SetMaxSize(2)
+			},
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(0, 16)
+			r.alloc = failAbove(4)
+			_, _ = r.Write([]byte(tt.prefill))
+
+			before, content := *r, r.String()
+			if err := tt.do(r); !errors.Is(err, ErrTooLarge) {
+				t.Fatalf("error = %v, want %v", err, ErrTooLarge)
+			}
+			r.alloc = before.alloc
+
+			if !reflect.DeepEqual(r.buf, before.buf) || r.pos != before.pos ||
+				r.written != before.written || r.ringMode != before.ringMode || r.maxSize != before.maxSize {
+				t.Errorf("state got = %+v, want unchanged %+v", r, &before)
+			}
+			if got := r.String(); got != content {
+				t.Errorf("String() got = %q, want unchanged %q", got, content)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_RotateLeft(t *testing.T) {
+	t.Parallel()
+
+	// rotate is the reference rotation, on a copy of the content
+	rotate := func(s string, k int) string {
+		if len(s) == 0 {
+			return s
+		}
+		k = ((k % len(s)) + len(s)) % len(s)
+		return s[k:] + s[:k]
+	}
+
+	states := []struct {
+		name   string
+		writes []string
+		drain  int
+	}{
+		{name: "empty"},
+		{name: "no ring", writes: []string{"abcde"}},
+		{name: "no ring, full", writes: []string{"abcdefg"}},
+		{name: "ring mode", writes: []string{"abcdefg", "hij"}},
+		{name: "drained", writes: []string{"abcdefg", "hij"}, drain: 2},
+	}
+
+	for _, st := range states {
+		for _, k := range []int{0, 1, 3, 7, 9, -1, -4} {
+			var st, k = st, k
+
+			t.Run(fmt.Sprintf("%s, k=%d", st.name, k), func(t *testing.T) {
+				t.Parallel()
+
+				r := NewRingBuffer(0, 7)
+				for _, w := range st.writes {
+					_, _ = r.Write([]byte(w))
+				}
+				r.Drain(st.drain)
+				content := r.String()
+
+				r.RotateLeft(k)
+				if got, want := r.String(), rotate(content, k); got != want {
+					t.Errorf("RotateLeft(%d) got = %q, want %q", k, got, want)
+				}
+				if err := r.Validate(); err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+
+				r.RotateRight(k)
+				if got := r.String(); got != content {
+					t.Errorf("RotateRight(%d) got = %q, want %q", k, got, content)
+				}
+			})
+		}
+	}
+}
+
+func TestRingBuffer_ReplaceRange(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		start, end  int
+		with        string
+		want        string
+		wantWritten int
+		wantErr     error
+	}{
+		// the content is "cdefgh", wrapped after 'f'
+		{name: "equal length, spanning the wrap", start: 2, end: 5, with: "XYZ", want: "cdXYZh", wantWritten: 8},
+		{name: "shorter", start: 2, end: 5, with: "X", want: "cdXh", wantWritten: 6},
+		{name: "deletion", start: 0, end: 6, with: "", want: "", wantWritten: 2},
+		{name: "single byte removed", start: 3, end: 4, with: "", want: "cdegh", wantWritten: 7},
+		{name: "longer, beyond the maximum size", start: 2, end: 4, with: "WXYZ", want: "WXYZgh", wantWritten: 10},
+		{name: "insertion at the end", start: 6, end: 6, with: "ij", want: "efghij", wantWritten: 10},
+		{name: "start after end", start: 4, end: 2, want: "cdefgh", wantWritten: 8, wantErr: ErrOutOfRange},
+		{name: "end beyond the content", start: 2, end: 7, want: "cdefgh", wantWritten: 8, wantErr: ErrOutOfRange},
+		{name: "negative start", start: -1, end: 2, want: "cdefgh", wantWritten: 8, wantErr: ErrOutOfRange},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(6, 6)
+			_, _ = r.Write([]byte("abcdef"))
+			_, _ = r.Write([]byte("gh"))
+
+			err := r.ReplaceRange(tt.start, tt.end, []byte(tt.with))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ReplaceRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := r.String(); got != tt.want {
+				t.Errorf("ReplaceRange() got = %q, want %q", got, tt.want)
+			}
+			if got := r.Written(); got != tt.wantWritten {
+				t.Errorf("Written() got = %d, want %d", got, tt.wantWritten)
+			}
+			if err := r.Validate(); err != nil {
+				t.Errorf("Validate() error = %v", err)
+			}
+
+			// it keeps working as a ring
+			_, _ = r.Write([]byte("0123456"))
+			if got := r.String(); got != "123456" {
+				t.Errorf("String() after Write got = %q, want %q", got, "123456")
+			}
+		})
+	}
+}
+
+func TestRingBuffer_ReplaceRange_frozen(t *testing.T) {
+	t.Parallel()
+
+	r := NewRingBuffer(0, 6)
+	_, _ = r.Write([]byte("abc"))
+	r.Freeze()
+
+	if err := r.ReplaceRange(0, 1, []byte("X")); err != ErrFrozen {
+		t.Errorf("ReplaceRange() error = %v, want %v", err, ErrFrozen)
+	}
+	if got := r.String(); got != "abc" {
+		t.Errorf("String() got = %q, want %q", got, "abc")
+	}
+}
+
+func TestRingBuffer_SplitAt(t *testing.T) {
+	t.Parallel()
+
+	wrapped := func() *RingBuffer {
+		return &RingBuffer{
+			buf:      []byte{'a', 'b', '1', '2', '3', 'f', 'g'},
+			pos:      5,
+			written:  17,
+			ringMode: true,
+			maxSize:  7,
+		}
+	}
+
+	tests := []struct {
+		name        string
+		inputBuffer *RingBuffer
+		n           int
+		maxSize     int
+		wantHead    string
+		wantTail    string
+		wantWritten [2]int
+	}{
+		{
+			name:        "empty",
+			inputBuffer: NewRingBuffer(0, 4),
+			n:           2,
+			maxSize:     7,
+			wantHead:    "",
+			wantTail:    "",
+			wantWritten: [2]int{0, 0},
+		},
+		{
+			name:        "wrapped, at the start",
+			inputBuffer: wrapped(),
+			n:           0,
+			maxSize:     7,
+			wantHead:    "",
+			wantTail:    "fgab123",
+			wantWritten: [2]int{0, 7},
+		},
+		{
+			name:        "wrapped, before the wrap",
+			inputBuffer: wrapped(),
+			n:           1,
+			maxSize:     7,
+			wantHead:    "f",
+			wantTail:    "gab123",
+			wantWritten: [2]int{1, 6},
+		},
+		{
+			name:        "wrapped, at the wrap",
+			inputBuffer: wrapped(),
+			n:           2,
+			maxSize:     7,
+			wantHead:    "fg",
+			wantTail:    "ab123",
+			wantWritten: [2]int{2, 5},
+		},
+		{
+			name:        "wrapped, after the wrap",
+			inputBuffer: wrapped(),
+			n:           4,
+			maxSize:     7,
+			wantHead:    "fgab",
+			wantTail:    "123",
+			wantWritten: [2]int{4, 3},
+		},
+		{
+			name:        "wrapped, beyond the end",
+			inputBuffer: wrapped(),
+			n:           10,
+			maxSize:     7,
+			wantHead:    "fgab123",
+			wantTail:    "",
+			wantWritten: [2]int{7, 0},
+		},
+		{
+			name:        "wrapped, negative",
+			inputBuffer: wrapped(),
+			n:           -1,
+			maxSize:     7,
+			wantHead:    "",
+			wantTail:    "fgab123",
+			wantWritten: [2]int{0, 7},
+		},
+		{
+			name:        "smaller maximum size",
+			inputBuffer: wrapped(),
+			n:           3,
+			maxSize:     3,
+			wantHead:    "fga",
+			wantTail:    "123",
+			wantWritten: [2]int{3, 4},
+		},
+		{
+			name:        "larger maximum size",
+			inputBuffer: wrapped(),
+			n:           3,
+			maxSize:     10,
+			wantHead:    "fga",
+			wantTail:    "b123",
+			wantWritten: [2]int{3, 4},
+		},
+		{
+			name:        "negative maximum size",
+			inputBuffer: wrapped(),
+			n:           3,
+			maxSize:     -1,
+			wantHead:    "",
+			wantTail:    "",
+			wantWritten: [2]int{3, 4},
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			before := tt.inputBuffer.String()
+			head, tail := tt.inputBuffer.SplitAt(tt.n, tt.maxSize)
+
+			if got := head.String(); got != tt.wantHead {
+				t.Errorf("SplitAt() head got = %q, want %q", got, tt.wantHead)
+			}
+			if got := tail.String(); got != tt.wantTail {
+				t.Errorf("SplitAt() tail got = %q, want %q", got, tt.wantTail)
+			}
+			if got := tt.inputBuffer.String(); got != before {
+				t.Errorf("String() after SplitAt() got = %q, want %q", got, before)
+			}
+
+			wantMaxSize := tt.maxSize
+			if wantMaxSize < 0 {
+				wantMaxSize = 0
+			}
+			for i, half := range []*RingBuffer{head, tail} {
+				if err := half.Validate(); err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				if got := half.Written(); got != tt.wantWritten[i] {
+					t.Errorf("Written() got = %d, want %d", got, tt.wantWritten[i])
+				}
+				if got := half.MaxSize(); got != wantMaxSize {
+					t.Errorf("MaxSize() got = %d, want %d", got, wantMaxSize)
+				}
+			}
+
+			// the halves are independent
+			_, _ = head.Write([]byte("xy"))
+			if got := tail.String(); got != tt.wantTail {
+				t.Errorf("tail after writing into head got = %q, want %q", got, tt.wantTail)
+			}
+		})
+	}
+}
+
+func TestMakeSlice(t *testing.T) {
+	t.Parallel()
+
+	b, err := makeSlice(3)
+	if err != nil || len(b) != 3 {
+		t.Errorf("makeSlice(3) got = %v, %v, want 3 bytes", b, err)
+	}
+
+	// a negative size makes make panic
+	_, err = makeSlice(-3)
+	if !errors.Is(err, ErrTooLarge) {
+		t.Errorf("makeSlice(-3) error = %v, want %v", err, ErrTooLarge)
+	}
+	if err != nil && !strings.Contains(err.Error(), "allocation of -3 bytes failed") {
+		t.Errorf("makeSlice(-3) error = %q, want the requested size", err)
+	}
+}
+
+func TestRingBuffer_Segments(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		inputBuffer *RingBuffer
+		wantFirst   []byte
+		wantSecond  []byte
+	}{
+		{
+			name:        "empty",
+			inputBuffer: NewRingBuffer(0, 4),
+			wantFirst:   []byte{},
+			wantSecond:  nil,
+		},
+		{
+			name: "no ring",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', 'c', 0},
+				pos:      3,
+				written:  3,
+				ringMode: false,
+				maxSize:  4,
+			},
+			wantFirst:  []byte("abc"),
+			wantSecond: nil,
+		},
+		{
+			name: "ring mode, start in the middle",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', '1', '2', '3', 'f', 'g'},
+				pos:      5,
+				written:  17,
+				ringMode: true,
+				maxSize:  7,
+			},
+			wantFirst:  []byte("fg"),
+			wantSecond: []byte("ab123"),
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			gotFirst, gotSecond := tt.inputBuffer.Segments()
+
+			if !reflect.DeepEqual(gotFirst, tt.wantFirst) || !reflect.DeepEqual(gotSecond, tt.wantSecond) {
+				t.Errorf("Segments() got = %q, %q, want %q, %q", gotFirst, gotSecond, tt.wantFirst, tt.wantSecond)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_StartIndex_EndIndex(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		inputBuffer *RingBuffer
+		wantStart   int
+		wantEnd     int
+	}{
+		{
+			name:        "empty",
+			inputBuffer: NewRingBuffer(0, 4),
+			wantStart:   0,
+			wantEnd:     0,
+		},
+		{
+			name: "no ring",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', 'c', 0},
+				pos:      3,
+				written:  3,
+				ringMode: false,
+				maxSize:  4,
+			},
+			wantStart: 0,
+			wantEnd:   3,
+		},
+		{
+			name: "ring mode, wrapped",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', '1', '2', '3', 'f', 'g'},
+				pos:      5,
+				written:  17,
+				ringMode: true,
+				maxSize:  7,
+			},
+			wantStart: 5,
+			wantEnd:   5,
+		},
+		{
+			name: "ring mode, not wrapped",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', 'c', 'd'},
+				pos:      0,
+				written:  8,
+				ringMode: true,
+				maxSize:  4,
+			},
+			wantStart: 0,
+			wantEnd:   0,
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			start, end := tt.inputBuffer.StartIndex(), tt.inputBuffer.EndIndex()
+			if start != tt.wantStart || end != tt.wantEnd {
+				t.Errorf("StartIndex(), EndIndex() got = %d, %d, want %d, %d", start, end, tt.wantStart, tt.wantEnd)
+			}
+
+			// the indices bracket the content
+			buf := tt.inputBuffer.Buffer()
+			var got []byte
+			if tt.inputBuffer.Len() > 0 && end <= start {
+				got = append(append(got, buf[start:]...), buf[:end]...)
+			} else {
+				got = append(got, buf[start:end]...)
+			}
+			if want := tt.inputBuffer.Bytes(); string(got) != string(want) {
+				t.Errorf("Buffer() content got = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_WritevBuffers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		inputBuffer *RingBuffer
+		wantLen     int
+	}{
+		{
+			name:        "empty",
+			inputBuffer: NewRingBuffer(0, 4),
+			wantLen:     0,
+		},
+		{
+			name: "no ring",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', 'c', 0},
+				pos:      3,
+				written:  3,
+				ringMode: false,
+				maxSize:  4,
+			},
+			wantLen: 1,
+		},
+		{
+			name: "ring mode, start on 0",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', 'c', 'd'},
+				pos:      0,
+				written:  8,
+				ringMode: true,
+				maxSize:  4,
+			},
+			wantLen: 1,
+		},
+		{
+			name: "ring mode, start in the middle",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', '1', '2', '3', 'f', 'g'},
+				pos:      5,
+				written:  17,
+				ringMode: true,
+				maxSize:  7,
+			},
+			wantLen: 2,
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			bufs := tt.inputBuffer.WritevBuffers()
+			if len(bufs) != tt.wantLen {
+				t.Errorf("WritevBuffers() got %d slices, want %d", len(bufs), tt.wantLen)
+			}
+
+			var out bytes.Buffer
+			if _, err := bufs.WriteTo(&out); err != nil {
+				t.Fatalf("WriteTo() error = %v", err)
+			}
+
+			if want := tt.inputBuffer.Bytes(); !bytes.Equal(out.Bytes(), want) {
+				t.Errorf("WritevBuffers() got = %q, want %q", out.Bytes(), want)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_WriteFmt(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		maxSize    int
+		format     string
+		args       []interface{}
+		wantN      int
+		wantString string
+	}{
+		{
+			name:       "no verbs",
+			maxSize:    32,
+			format:     "hello",
+			wantN:      5,
+			wantString: "hello",
+		},
+		{
+			name:       "various verbs",
+			maxSize:    32,
+			format:     "%s=%d %q %x %.2f",
+			args:       []interface{}{"n", 42, "q", 255, 1.5},
+			wantN:      16,
+			wantString: `n=42 "q" ff 1.50`,
+		},
+		{
+			name:       "eviction",
+			maxSize:    8,
+			format:     "line %03d: %v",
+			args:       []interface{}{7, true},
+			wantN:      14,
+			wantString: "07: true",
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(0, tt.maxSize)
+			n, err := r.WriteFmt(tt.format, tt.args...)
+
+			if err != nil {
+				t.Errorf("WriteFmt() error = %v", err)
+			}
+			if n != tt.wantN {
+				t.Errorf("WriteFmt() got = %d, want %d", n, tt.wantN)
+			}
+			if got := r.String(); got != tt.wantString {
+				t.Errorf("String() got = %q, want %q", got, tt.wantString)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_SetMaxSize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		inputBuffer *RingBuffer
+		maxSize     int
+		wantBuffer  *RingBuffer
+	}{
+		{
+			name: "greater, no ring",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', 'c', 0},
+				pos:      3,
+				written:  3,
+				ringMode: false,
+				maxSize:  4,
+			},
+			maxSize: 10,
+			wantBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', 'c', 0},
+				pos:      3,
+				written:  3,
+				ringMode: false,
+				maxSize:  10,
+			},
+		},
+		{
+			name: "greater, ring mode",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'e', 'b', 'c', 'd'},
+				pos:      1,
+				written:  5,
+				ringMode: true,
+				maxSize:  4,
+			},
+			maxSize: 10,
+			wantBuffer: &RingBuffer{
+				buf:      []byte{'b', 'c', 'd', 'e'},
+				pos:      4,
+				written:  5,
+				ringMode: false,
+				maxSize:  10,
+			},
+		},
+		{
+			name: "lower, content fits",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', 0, 0, 0, 0},
+				pos:      2,
+				written:  2,
+				ringMode: false,
+				maxSize:  8,
+			},
+			maxSize: 3,
+			wantBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', 0},
+				pos:      2,
+				written:  2,
+				ringMode: false,
+				maxSize:  3,
+			},
+		},
+		{
+			name: "lower, content and length fit, spare capacity",
+			inputBuffer: &RingBuffer{
+				buf:      make([]byte, 2, 8),
+				pos:      2,
+				written:  2,
+				ringMode: false,
+				maxSize:  8,
+			},
+			maxSize: 4,
+			wantBuffer: &RingBuffer{
+				buf:      make([]byte, 2),
+				pos:      2,
+				written:  2,
+				ringMode: false,
+				maxSize:  4,
+			},
+		},
+		{
+			name: "lower, ring mode, keep only the newest",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', '1', '2', '3', 'f', 'g'},
+				pos:      5,
+				written:  17,
+				ringMode: true,
+				maxSize:  7,
+			},
+			maxSize: 4,
+			wantBuffer: &RingBuffer{
+				buf:      []byte{'b', '1', '2', '3'},
+				pos:      4,
+				written:  17,
+				ringMode: false,
+				maxSize:  4,
+				midLine:  true,
+				readNext: 3,
+			},
+		},
+		{
+			name: "lower, ring mode, drop within the first segment",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', '1', '2', '3', 'f', 'g'},
+				pos:      5,
+				written:  17,
+				ringMode: true,
+				maxSize:  7,
+			},
+			maxSize: 6,
+			wantBuffer: &RingBuffer{
+				buf:      []byte{'g', 'a', 'b', '1', '2', '3'},
+				pos:      6,
+				written:  17,
+				ringMode: false,
+				maxSize:  6,
+				midLine:  true,
+				readNext: 1,
+			},
+		},
+		{
+			name: "negative",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', 0},
+				pos:      2,
+				written:  2,
+				ringMode: false,
+				maxSize:  3,
+			},
+			maxSize: -1,
+			wantBuffer: &RingBuffer{
+				buf:      []byte{},
+				pos:      0,
+				written:  2,
+				ringMode: false,
+				maxSize:  0,
+				midLine:  true,
+				readNext: 2,
+			},
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := tt.inputBuffer.SetMaxSize(tt.maxSize); err != nil {
+				t.Fatalf("SetMaxSize() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(tt.inputBuffer, tt.wantBuffer) {
+				t.Errorf("SetMaxSize() got = %+v want %+v", tt.inputBuffer, tt.wantBuffer)
+			}
+			if err := tt.inputBuffer.Validate(); err != nil {
+				t.Errorf("Validate() error = %v", err)
+			}
+			if got := tt.inputBuffer.Cap(); got > tt.maxSize && tt.maxSize >= 0 {
+				t.Errorf("Cap() got = %d, want <= %d", got, tt.maxSize)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_ReverseBytes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		inputBuffer *RingBuffer
+		want        []byte
+	}{
+		{
+			name:        "empty",
+			inputBuffer: NewRingBuffer(0, 4),
+			want:        []byte{},
+		},
+		{
+			name: "no ring",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', 'c', 0},
+				pos:      3,
+				written:  3,
+				ringMode: false,
+				maxSize:  4,
+			},
+			want: []byte("cba"),
+		},
+		{
+			name: "ring mode, start in the middle",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', '1', '2', '3', 'f', 'g'},
+				pos:      5,
+				written:  17,
+				ringMode: true,
+				maxSize:  7,
+			},
+			want: []byte("321bagf"),
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := tt.inputBuffer.ReverseBytes()
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReverseBytes() got = %q, want %q", got, tt.want)
+			}
+
+			// same as reversing a copy of the content
+			want := tt.inputBuffer.Bytes()
+			reverse(want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("ReverseBytes() got = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_MapInPlace(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		inputBuffer *RingBuffer
+		wantBuf     []byte
+	}{
+		{
+			name: "empty",
+			inputBuffer: &RingBuffer{
+				buf:     []byte{'a', 'b'},
+				maxSize: 4,
+			},
+			wantBuf: []byte("ab"),
+		},
+		{
+			name: "no ring, stale bytes beyond the content",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', 'c', 'd', 'e'},
+				pos:      3,
+				written:  5,
+				ringMode: false,
+				maxSize:  5,
+			},
+			wantBuf: []byte("ABCde"),
+		},
+		{
+			name: "ring mode, wrapped",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', '1', '2', '3', 'f', 'g'},
+				pos:      5,
+				written:  17,
+				ringMode: true,
+				maxSize:  7,
+			},
+			wantBuf: []byte("AB123FG"),
+		},
+		{
+			name: "frozen",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', 'c'},
+				pos:      3,
+				written:  3,
+				ringMode: false,
+				maxSize:  3,
+				frozen:   true,
+			},
+			wantBuf: []byte("abc"),
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			before := tt.inputBuffer.Bytes()
+			tt.inputBuffer.MapInPlace(func(b byte) byte {
+				if b >= 'a' && b <= 'z' {
+					return b - 'a' + 'A'
+				}
+				return b
+			})
+
+			if !reflect.DeepEqual(tt.inputBuffer.buf, tt.wantBuf) {
+				t.Errorf("MapInPlace() buf = %q, want %q", tt.inputBuffer.buf, tt.wantBuf)
+			}
+
+			want := before
+			if !tt.inputBuffer.frozen {
+				want = bytes.ToUpper(before)
+			}
+			if got := tt.inputBuffer.Bytes(); !reflect.DeepEqual(got, want) {
+				t.Errorf("Bytes() got = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_MapInPlace_NoAllocation(t *testing.T) {
+	r := NewRingBuffer(8, 8)
+	_, _ = r.Write([]byte("hello, world"))
+
+	allocs := testing.AllocsPerRun(10, func() {
+		r.MapInPlace(func(b byte) byte { return b ^ 0x20 })
+	})
+	if allocs != 0 {
+		t.Errorf("MapInPlace() allocs = %v, want 0", allocs)
+	}
+}
+
+func TestRingBuffer_Fill(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		inputBuffer *RingBuffer
+		c           byte
+		n           int
+		wantN       int
+		wantBuffer  *RingBuffer
+	}{
+		{
+			name:        "nothing",
+			inputBuffer: NewRingBuffer(0, 10),
+			c:           'A',
+			n:           0,
+			wantN:       0,
+			wantBuffer: &RingBuffer{
+				buf:     []byte{},
+				maxSize: 10,
+			},
+		},
+		{
+			name:        "negative",
+			inputBuffer: NewRingBuffer(0, 10),
+			c:           'A',
+			n:           -1,
+			wantN:       0,
+			wantBuffer: &RingBuffer{
+				buf:     []byte{},
+				maxSize: 10,
+			},
+		},
+		{
+			name:        "no ring",
+			inputBuffer: NewRingBuffer(0, 10),
+			c:           'A',
+			n:           3,
+			wantN:       3,
+			wantBuffer: &RingBuffer{
+				buf:      []byte{'A', 'A', 'A', 0},
+				pos:      3,
+				written:  3,
+				ringMode: false,
+				maxSize:  10,
+			},
+		},
+		{
+			name:        "evicted",
+			inputBuffer: NewRingBuffer(0, 10),
+			c:           65,
+			n:           100,
+			wantN:       100,
+			wantBuffer: &RingBuffer{
+				buf:      []byte("AAAAAAAAAA"),
+				pos:      0,
+				written:  100,
+				ringMode: true,
+				maxSize:  10,
+				midLine:  true,
+			},
+		},
+		{
+			name:        "more than a chunk",
+			inputBuffer: NewRingBuffer(0, 4),
+			c:           'z',
+			n:           fillChunkSize*2 + 3,
+			wantN:       fillChunkSize*2 + 3,
+			wantBuffer: &RingBuffer{
+				buf:      []byte("zzzz"),
+				pos:      3,
+				written:  fillChunkSize*2 + 3,
+				ringMode: true,
+				maxSize:  4,
+				midLine:  true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			n, err := tt.inputBuffer.Fill(tt.c, tt.n)
+
+			if err != nil || n != tt.wantN {
+				t.Errorf("Fill() got = %d, %v, want %d, nil", n, err, tt.wantN)
+			}
+
+			if !reflect.DeepEqual(tt.inputBuffer, tt.wantBuffer) {
+				t.Errorf("Fill() got = %+v want %+v", tt.inputBuffer, tt.wantBuffer)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_WriteMulti(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		prefill string
+		opts    []Option
+		batch   []string
+	}{
+		{
+			name:  "empty batch",
+			batch: nil,
+		},
+		{
+			name:  "fits",
+			batch: []string{"ab", "", "cd"},
+		},
+		{
+			name:    "fills exactly",
+			prefill: "xy",
+			batch:   []string{"abc", "defg"},
+		},
+		{
+			name:    "last slice overwrites everything",
+			prefill: "xy",
+			batch:   []string{"abc", "de", "0123456789"},
+		},
+		{
+			name:    "skip a prefix of a slice",
+			prefill: "xyz",
+			batch:   []string{"abcdef", "gh", "ijk"},
+		},
+		{
+			name:    "skip whole slices",
+			prefill: "xyz",
+			batch:   []string{"abcdefgh", "ijklmnop", "q", "rstuvw"},
+		},
+		{
+			name:    "dedup",
+			prefill: "xyz",
+			opts:    []Option{WithDedup()},
+			batch:   []string{"abcdefgh", "abcdefgh", "q", "q"},
+		},
+		{
+			name:    "reject policy",
+			prefill: "xyz",
+			opts:    []Option{WithOverflowPolicy(OverflowReject)},
+			batch:   []string{"abc", "de"},
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := NewRingBuffer(0, 8, tt.opts...)
+			want := NewRingBuffer(0, 8, tt.opts...)
+			_, _ = got.Write([]byte(tt.prefill))
+			_, _ = want.Write([]byte(tt.prefill))
+
+			batch := make([][]byte, len(tt.batch))
+			wantN := 0
+			for i, p := range tt.batch {
+				batch[i] = []byte(p)
+				n, _ := want.Write(batch[i])
+				wantN += n
+			}
+
+			n, err := got.WriteMulti(batch...)
+
+			if err != nil || n != wantN {
+				t.Errorf("WriteMulti() got = %d, %v, want %d, nil", n, err, wantN)
+			}
+			if got.String() != want.String() {
+				t.Errorf("String() got = %q, want %q", got.String(), want.String())
+			}
+			if got.Written() != want.Written() {
+				t.Errorf("Written() got = %d, want %d", got.Written(), want.Written())
+			}
+			if err := got.Validate(); err != nil {
+				t.Errorf("Validate() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestKeepLast(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		batch []string
+		n     int
+		want  []string
+	}{
+		{
+			name:  "everything fits",
+			batch: []string{"ab", "cd"},
+			n:     8,
+			want:  []string{"ab", "cd"},
+		},
+		{
+			name:  "trim the first kept",
+			batch: []string{"abcdef", "gh", "ijk"},
+			n:     8,
+			want:  []string{"def", "gh", "ijk"},
+		},
+		{
+			name:  "skip whole slices",
+			batch: []string{"abcdefgh", "ijklmnop", "q", "rstuvw"},
+			n:     8,
+			want:  []string{"p", "q", "rstuvw"},
+		},
+		{
+			name:  "last one is enough",
+			batch: []string{"abc", "0123456789"},
+			n:     8,
+			want:  []string{"23456789"},
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			batch := make([][]byte, len(tt.batch))
+			for i, p := range tt.batch {
+				batch[i] = []byte(p)
+			}
+
+			kept := keepLast(batch, tt.n)
+
+			got := make([]string, len(kept))
+			for i, p := range kept {
+				got[i] = string(p)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("keepLast() got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_AppendSegments(t *testing.T) {
+	t.Parallel()
+
+	wrapped := func() *RingBuffer {
+		return &RingBuffer{
+			buf:      []byte{'a', 'b', '1', '2', '3', 'f', 'g'},
+			pos:      5,
+			written:  17,
+			ringMode: true,
+			maxSize:  7,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		src     *RingBuffer
+		prefill string
+		maxSize int
+		opts    []Option
+	}{
+		{
+			name:    "empty source",
+			src:     NewRingBuffer(0, 4),
+			prefill: "xy",
+			maxSize: 8,
+		},
+		{
+			name:    "contiguous source",
+			src:     NewRingBufferUsing([]byte("abc"), 4),
+			prefill: "xy",
+			maxSize: 8,
+		},
+		{
+			name:    "wrapped source",
+			src:     wrapped(),
+			prefill: "xy",
+			maxSize: 16,
+		},
+		{
+			name:    "wrapped source, overflowing",
+			src:     wrapped(),
+			prefill: "xy",
+			maxSize: 5,
+		},
+		{
+			name:    "wrapped source, reject policy",
+			src:     wrapped(),
+			prefill: "xy",
+			maxSize: 5,
+			opts:    []Option{WithOverflowPolicy(OverflowReject)},
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			want := NewRingBuffer(0, tt.maxSize, tt.opts...)
+			_, _ = want.Write([]byte(tt.prefill))
+			wantN, wantErr := want.Write(tt.src.Bytes())
+
+			r := NewRingBuffer(0, tt.maxSize, tt.opts...)
+			_, _ = r.Write([]byte(tt.prefill))
+			n, err := r.AppendSegments(tt.src)
+
+			if n != wantN || fmt.Sprint(err) != fmt.Sprint(wantErr) {
+				t.Errorf("AppendSegments() got = %d, %v, want %d, %v", n, err, wantN, wantErr)
+			}
+			if got := r.String(); got != want.String() {
+				t.Errorf("String() got = %q, want %q", got, want.String())
+			}
+			if got := r.Written(); got != want.Written() {
+				t.Errorf("Written() got = %d, want %d", got, want.Written())
+			}
+		})
+	}
+}
+
+func TestRingBuffer_CanMergeInto(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		src        string
+		dst        string
+		dstMax     int
+		frozen     bool
+		want       bool
+		wantReason string
+	}{
+		{
+			name:   "fits",
+			src:    "abc",
+			dst:    "de",
+			dstMax: 8,
+			want:   true,
+		},
+		{
+			name:   "exactly the maximum size",
+			src:    "abcd",
+			dst:    "efgh",
+			dstMax: 8,
+			want:   true,
+		},
+		{
+			name:       "overflows the destination",
+			src:        "abcde",
+			dst:        "fgh",
+			dstMax:     6,
+			want:       false,
+			wantReason: "2 bytes of the destination would be overwritten: 3 + 5 bytes exceed the maximum size 6",
+		},
+		{
+			name:       "larger than the destination",
+			src:        "abcdefg",
+			dstMax:     6,
+			want:       false,
+			wantReason: "7 bytes don't fit in the maximum size 6",
+		},
+		{
+			name:       "frozen destination",
+			src:        "a",
+			dstMax:     6,
+			frozen:     true,
+			want:       false,
+			wantReason: "the destination can't be written: " + ErrFrozen.Error(),
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			src := NewRingBuffer(0, 16)
+			_, _ = src.WriteString(tt.src)
+			dst := NewRingBuffer(0, tt.dstMax)
+			_, _ = dst.WriteString(tt.dst)
+			if tt.frozen {
+				dst.Freeze()
+			}
+
+			got, reason := src.CanMergeInto(dst)
+			if got != tt.want || reason != tt.wantReason {
+				t.Errorf("CanMergeInto() got = %v, %q, want %v, %q", got, reason, tt.want, tt.wantReason)
+			}
+
+			// when it can, nothing is lost for real
+			if got {
+				_, _ = dst.AppendSegments(src)
+				if want := tt.dst + tt.src; dst.String() != want {
+					t.Errorf("AppendSegments() got = %q, want %q", dst.String(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestRingBuffer_AppendSegments_self(t *testing.T) {
+	t.Parallel()
+
+	r := NewRingBuffer(0, 8)
+	_, _ = r.Write([]byte("abcdef"))
+
+	if _, err := r.AppendSegments(r); err != nil {
+		t.Fatalf("AppendSegments() error = %v", err)
+	}
+	if got := r.String(); got != "efabcdef" {
+		t.Errorf("String() got = %q, want %q", got, "efabcdef")
+	}
+}
+
+func BenchmarkRingBuffer_AppendSegments(b *testing.B) {
+	src := NewRingBuffer(0, 4096)
+	for i := 0; i < 3; i++ {
+		_, _ = src.Write(bytes.Repeat([]byte{'a' + byte(i)}, 1500))
+	}
+
+	b.Run("AppendSegments", func(b *testing.B) {
+		b.ReportAllocs()
+		r := NewRingBuffer(1<<16, 1<<16)
+		for i := 0; i < b.N; i++ {
+			_, _ = r.AppendSegments(src)
+		}
+	})
+
+	b.Run("Write(Bytes())", func(b *testing.B) {
+		b.ReportAllocs()
+		r := NewRingBuffer(1<<16, 1<<16)
+		for i := 0; i < b.N; i++ {
+			_, _ = r.Write(src.Bytes())
+		}
+	})
+}
+
+func BenchmarkRingBuffer_WriteMulti(b *testing.B) {
+	batch := make([][]byte, 64)
+	for i := range batch {
+		batch[i] = bytes.Repeat([]byte{'a' + byte(i%26)}, 4096)
+	}
+
+	b.Run("WriteMulti", func(b *testing.B) {
+		r := NewRingBuffer(1024, 1024)
+		for i := 0; i < b.N; i++ {
+			_, _ = r.WriteMulti(batch...)
+		}
+	})
+
+	b.Run("Write", func(b *testing.B) {
+		r := NewRingBuffer(1024, 1024)
+		for i := 0; i < b.N; i++ {
+			for _, p := range batch {
+				_, _ = r.Write(p)
+			}
+		}
+	})
+}
+
+func BenchmarkRingBuffer_Read(b *testing.B) {
+	b.ReportAllocs()
+	r := NewRingBuffer(1<<20, 1<<20)
+	p := make([]byte, 512)
+	for i := 0; i < b.N; i++ {
+		_, _ = r.Fill('a', 1<<20+1)
+		for r.Len() > 0 {
+			_, _ = r.Read(p)
+		}
+	}
+}
+
+func TestRingBuffer_FirstLast(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		inputBuffer *RingBuffer
+		wantFirst   byte
+		wantLast    byte
+		wantOk      bool
+	}{
+		{
+			name:        "empty",
+			inputBuffer: NewRingBuffer(0, 4),
+			wantOk:      false,
+		},
+		{
+			name: "single byte",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 0},
+				pos:      1,
+				written:  1,
+				ringMode: false,
+				maxSize:  4,
+			},
+			wantFirst: 'a',
+			wantLast:  'a',
+			wantOk:    true,
+		},
+		{
+			name: "ring mode, start on end",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', 'c', 'd'},
+				pos:      4,
+				written:  8,
+				ringMode: true,
+				maxSize:  4,
+			},
+			wantFirst: 'a',
+			wantLast:  'd',
+			wantOk:    true,
+		},
+		{
+			name: "ring mode, start in the middle",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', '1', '2', '3', 'f', 'g'},
+				pos:      5,
+				written:  17,
+				ringMode: true,
+				maxSize:  7,
+			},
+			wantFirst: 'f',
+			wantLast:  '3',
+			wantOk:    true,
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			first, ok := tt.inputBuffer.First()
+			if first != tt.wantFirst || ok != tt.wantOk {
+				t.Errorf("First() got = %q, %v, want %q, %v", first, ok, tt.wantFirst, tt.wantOk)
+			}
+
+			last, ok := tt.inputBuffer.Last()
+			if last != tt.wantLast || ok != tt.wantOk {
+				t.Errorf("Last() got = %q, %v, want %q, %v", last, ok, tt.wantLast, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_WriteWith(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		inputBuffer *RingBuffer
+		n           int
+		fill        string
+		report      int
+		wantInPlace bool
+		wantN       int
+		wantBuffer  *RingBuffer
+	}{
+		{
+			name:        "no ring, contiguous",
+			inputBuffer: NewRingBuffer(2, 8),
+			n:           3,
+			fill:        "abc",
+			report:      3,
+			wantInPlace: true,
+			wantN:       3,
+			wantBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', 'c', 0},
+				pos:      3,
+				written:  3,
+				ringMode: false,
+				maxSize:  8,
+			},
+		},
+		{
+			name: "no ring, partial commit",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', 0, 0},
+				pos:      2,
+				written:  2,
+				ringMode: false,
+				maxSize:  4,
+			},
+			n:           2,
+			fill:        "c",
+			report:      1,
+			wantInPlace: true,
+			wantN:       1,
+			wantBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', 'c', 0},
+				pos:      3,
+				written:  3,
+				ringMode: false,
+				maxSize:  4,
+			},
+		},
+		{
+			name: "ring mode, contiguous",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', '1', '2', '3', 'f', 'g'},
+				pos:      2,
+				written:  14,
+				ringMode: true,
+				maxSize:  7,
+			},
+			n:           3,
+			fill:        "xyz",
+			report:      3,
+			wantInPlace: true,
+			wantN:       3,
+			wantBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', 'x', 'y', 'z', 'f', 'g'},
+				pos:      5,
+				written:  17,
+				ringMode: true,
+				maxSize:  7,
+				midLine:  true,
+			},
+		},
+		{
+			name: "ring mode, across the wrap",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', '1', '2', '3', 'f', 'g'},
+				pos:      5,
+				written:  17,
+				ringMode: true,
+				maxSize:  7,
+			},
+			n:           4,
+			fill:        "wxyz",
+			report:      4,
+			wantInPlace: false,
+			wantN:       4,
+			wantBuffer: &RingBuffer{
+				buf:      []byte{'y', 'z', '1', '2', '3', 'w', 'x'},
+				pos:      2,
+				written:  21,
+				ringMode: true,
+				maxSize:  7,
+				midLine:  true,
+			},
+		},
+		{
+			name: "no ring, filling past maxSize",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b', 'c', 0},
+				pos:      3,
+				written:  3,
+				ringMode: false,
+				maxSize:  4,
+			},
+			n:           2,
+			fill:        "de",
+			report:      2,
+			wantInPlace: false,
+			wantN:       2,
+			wantBuffer: &RingBuffer{
+				buf:      []byte{'e', 'b', 'c', 'd'},
+				pos:      1,
+				written:  5,
+				ringMode: true,
+				maxSize:  4,
+				midLine:  true,
+			},
+		},
+		{
+			name:        "report out of range",
+			inputBuffer: NewRingBuffer(0, 8),
+			n:           2,
+			fill:        "ab",
+			report:      10,
+			wantInPlace: true,
+			wantN:       2,
+			wantBuffer: &RingBuffer{
+				buf:      []byte{'a', 'b'},
+				pos:      2,
+				written:  2,
+				ringMode: false,
+				maxSize:  8,
+			},
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var inPlace bool
+			n, err := tt.inputBuffer.WriteWith(tt.n, func(dst []byte) int {
+				inPlace = len(tt.inputBuffer.buf) > 0 && &dst[0] == &tt.inputBuffer.buf[tt.inputBuffer.pos]
+				if len(dst) != tt.n {
+					t.Errorf("WriteWith() dst length got = %d, want %d", len(dst), tt.n)
+				}
+				copy(dst, tt.fill)
+				return tt.report
+			})
+
+			if err != nil || n != tt.wantN {
+				t.Errorf("WriteWith() got = %d, %v, want %d, nil", n, err, tt.wantN)
+			}
+			if inPlace != tt.wantInPlace {
+				t.Errorf("WriteWith() in place got = %v, want %v", inPlace, tt.wantInPlace)
+			}
+			if !reflect.DeepEqual(tt.inputBuffer, tt.wantBuffer) {
+				t.Errorf("WriteWith() got = %+v want %+v", tt.inputBuffer, tt.wantBuffer)
+			}
+		})
+	}
+}
+
+func BenchmarkRingBuffer_Grow_capacityHeadroom(b *testing.B) {
+	r := NewRingBufferUsing(make([]byte, 0, 4096), 4096)
+	p := make([]byte, 64)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.buf = r.buf[:0]
+		r.Reset()
+		for j := 0; j < 64; j++ {
+			_, _ = r.Write(p)
+		}
+	}
+}
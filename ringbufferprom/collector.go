@@ -0,0 +1,97 @@
+// Package ringbufferprom exports the statistics of a group of RingBuffers as
+// Prometheus metrics.
+// It is a separate module, so that the ringbuffer package itself doesn't
+// depend on the Prometheus client.
+package ringbufferprom
+
+import (
+	"sync"
+
+	"github.com/lucianoq/ringbuffer"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// StatsSource is a buffer whose statistics can be collected, i.e. a
+// *ringbuffer.RingBuffer or a *ringbuffer.MPSCRingBuffer.
+type StatsSource interface {
+	Stats() ringbuffer.Stats
+}
+
+// Collector is a prometheus.Collector exporting, for every registered
+// buffer, the gauges ringbuffer_cap_bytes, ringbuffer_len_bytes,
+// ringbuffer_written_bytes, ringbuffer_dropped_bytes and
+// ringbuffer_fill_ratio, labelled with the name the buffer was registered
+// with.
+// The statistics are read on every scrape, concurrently with the rest of the
+// program, so a buffer used by other goroutines at the same time must be
+// safe for concurrent use, like MPSCRingBuffer.
+type Collector struct {
+	mu      sync.Mutex
+	sources map[string]StatsSource
+
+	cap       *prometheus.Desc
+	len       *prometheus.Desc
+	written   *prometheus.Desc
+	dropped   *prometheus.Desc
+	fillRatio *prometheus.Desc
+}
+
+// interfaces implemented by Collector
+var _ prometheus.Collector = (*Collector)(nil)
+
+// NewCollector creates a new Collector with no buffers registered.
+func NewCollector() *Collector {
+	labels := []string{"name"}
+	return &Collector{
+		sources: map[string]StatsSource{},
+		cap: prometheus.NewDesc("ringbuffer_cap_bytes",
+			"Size of memory allocated for the buffer.", labels, nil),
+		len: prometheus.NewDesc("ringbuffer_len_bytes",
+			"Number of bytes of content held by the buffer.", labels, nil),
+		written: prometheus.NewDesc("ringbuffer_written_bytes",
+			"Number of bytes written to the buffer.", labels, nil),
+		dropped: prometheus.NewDesc("ringbuffer_dropped_bytes",
+			"Number of bytes written but no longer held by the buffer.", labels, nil),
+		fillRatio: prometheus.NewDesc("ringbuffer_fill_ratio",
+			"Length of the content over the maximum size of the buffer.", labels, nil),
+	}
+}
+
+// Register adds s to the exported buffers, with the given name as label.
+// A buffer already registered with the same name is replaced.
+func (c *Collector) Register(name string, s StatsSource) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sources[name] = s
+}
+
+// Unregister removes the buffer registered with the given name.
+func (c *Collector) Unregister(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.sources, name)
+}
+
+// Describe sends the descriptors of the exported metrics to ch.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.cap
+	ch <- c.len
+	ch <- c.written
+	ch <- c.dropped
+	ch <- c.fillRatio
+}
+
+// Collect sends the current statistics of every registered buffer to ch.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for name, s := range c.sources {
+		st := s.Stats()
+		ch <- prometheus.MustNewConstMetric(c.cap, prometheus.GaugeValue, float64(st.Cap), name)
+		ch <- prometheus.MustNewConstMetric(c.len, prometheus.GaugeValue, float64(st.Len), name)
+		ch <- prometheus.MustNewConstMetric(c.written, prometheus.GaugeValue, float64(st.Written), name)
+		ch <- prometheus.MustNewConstMetric(c.dropped, prometheus.GaugeValue, float64(st.Dropped), name)
+		ch <- prometheus.MustNewConstMetric(c.fillRatio, prometheus.GaugeValue, st.FillRatio, name)
+	}
+}
@@ -0,0 +1,55 @@
+package ringbufferprom
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lucianoq/ringbuffer"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	t.Parallel()
+
+	logs := ringbuffer.NewRingBuffer(4, 8)
+	_, _ = logs.Write([]byte("abcdefghijkl"))
+
+	events := ringbuffer.NewMPSCRingBuffer(2, 16)
+	_, _ = events.Write([]byte("abcd"))
+
+	c := NewCollector()
+	c.Register("logs", logs)
+	c.Register("events", events)
+	c.Register("removed", ringbuffer.NewRingBuffer(1, 1))
+	c.Unregister("removed")
+
+	want := `
+# HELP ringbuffer_cap_bytes Size of memory allocated for the buffer.
+# TYPE ringbuffer_cap_bytes gauge
+ringbuffer_cap_bytes{name="events"} 4
+ringbuffer_cap_bytes{name="logs"} 8
+# HELP ringbuffer_dropped_bytes Number of bytes written but no longer held by the buffer.
+# TYPE ringbuffer_dropped_bytes gauge
+ringbuffer_dropped_bytes{name="events"} 0
+ringbuffer_dropped_bytes{name="logs"} 4
+# HELP ringbuffer_fill_ratio Length of the content over the maximum size of the buffer.
+# TYPE ringbuffer_fill_ratio gauge
+ringbuffer_fill_ratio{name="events"} 0.25
+ringbuffer_fill_ratio{name="logs"} 1
+# HELP ringbuffer_len_bytes Number of bytes of content held by the buffer.
+# TYPE ringbuffer_len_bytes gauge
+ringbuffer_len_bytes{name="events"} 4
+ringbuffer_len_bytes{name="logs"} 8
+# HELP ringbuffer_written_bytes Number of bytes written to the buffer.
+# TYPE ringbuffer_written_bytes gauge
+ringbuffer_written_bytes{name="events"} 4
+ringbuffer_written_bytes{name="logs"} 12
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want)); err != nil {
+		t.Errorf("CollectAndCompare() error = %v", err)
+	}
+
+	if got, want := testutil.CollectAndCount(c), 10; got != want {
+		t.Errorf("CollectAndCount() got = %d, want %d", got, want)
+	}
+}
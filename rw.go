@@ -0,0 +1,63 @@
+package ringbuffer
+
+import (
+	"fmt"
+	"io"
+)
+
+// RingRW is an in-memory transport over a RingBuffer: what is written can be
+// read back in order, e.g. to stand in for a connection in tests.
+// Read consumes the content like RingBuffer.Read, returning io.EOF when
+// there is nothing to read, like bytes.Buffer.
+// When the writer gets more than the maximum size ahead of the reader, the
+// unread content is evicted as usual: the next Read returns the retained
+// content together with an error wrapping ErrEvicted, reporting how many
+// bytes have been lost, and the following reads continue normally.
+// A RingRW is not safe for concurrent use.
+type RingRW struct {
+	rb *RingBuffer
+
+	// total of the gaps reported by the reads of rb
+	lost int64
+}
+
+// interfaces implemented by RingRW
+var _ io.ReadWriteCloser = (*RingRW)(nil)
+
+// NewReadWriter creates a new RingRW over a RingBuffer created with the same
+// parameters of NewRingBuffer.
+func NewReadWriter(initialSize, maxSize int) *RingRW {
+	return &RingRW{
+		rb: NewRingBuffer(initialSize, maxSize),
+	}
+}
+
+// Write appends the contents of p, like RingBuffer.Write.
+// After Close, it returns ErrClosed.
+func (rw *RingRW) Write(p []byte) (int, error) {
+	return rw.rb.Write(p)
+}
+
+// Read reads and consumes the oldest unread bytes.
+// If some bytes have been evicted before being read, it returns the retained
+// ones and an error wrapping ErrEvicted.
+func (rw *RingRW) Read(p []byte) (int, error) {
+	n, err := rw.rb.Read(p)
+
+	if gap := rw.rb.ReadGap(); gap > 0 {
+		rw.lost += gap
+		err = fmt.Errorf("%w: %d unread bytes lost", ErrEvicted, gap)
+	}
+	return n, err
+}
+
+// Lost returns the total number of bytes evicted before being read.
+func (rw *RingRW) Lost() int64 {
+	return rw.lost
+}
+
+// Close releases the underlying buffer: the unread content is dropped,
+// Write returns ErrClosed and Read returns io.EOF.
+func (rw *RingRW) Close() error {
+	return rw.rb.Close()
+}
@@ -0,0 +1,100 @@
+package ringbuffer
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestRingRW(t *testing.T) {
+	t.Parallel()
+
+	type step struct {
+		write    string
+		drain    int
+		read     int
+		want     string
+		wantErr  error
+		wantLost int64
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "write then read",
+			steps: []step{
+				{write: "hello", read: 3, want: "hel"},
+				{write: " world", read: 10, want: "lo world"},
+				{read: 1, want: "", wantErr: io.EOF},
+			},
+		},
+		{
+			name: "reader falling behind",
+			steps: []step{
+				{write: "abc", read: 1, want: "a"},
+				{write: "defghijk", read: 4, want: "defg", wantErr: ErrEvicted, wantLost: 2},
+				{read: 10, want: "hijk", wantLost: 2},
+				{write: "0123456789", read: 10, want: "23456789", wantErr: ErrEvicted, wantLost: 4},
+			},
+		},
+		{
+			name: "drained by the underlying buffer",
+			steps: []step{
+				{write: "abcdefgh", drain: 3, read: 10, want: "defgh"},
+			},
+		},
+		{
+			name: "empty read before the gap",
+			steps: []step{
+				{write: "abcdefghij", read: 0, want: ""},
+				{read: 10, want: "cdefghij", wantErr: ErrEvicted, wantLost: 2},
+			},
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rw := NewReadWriter(0, 8)
+			for _, s := range tt.steps {
+				if _, err := rw.Write([]byte(s.write)); err != nil {
+					t.Fatalf("Write() error = %v", err)
+				}
+				rw.rb.Drain(s.drain)
+
+				p := make([]byte, s.read)
+				n, err := rw.Read(p)
+				if !errors.Is(err, s.wantErr) {
+					t.Errorf("Read() error = %v, wantErr %v", err, s.wantErr)
+				}
+				if got := string(p[:n]); got != s.want {
+					t.Errorf("Read() got = %q, want %q", got, s.want)
+				}
+				if got := rw.Lost(); got != s.wantLost {
+					t.Errorf("Lost() got = %d, want %d", got, s.wantLost)
+				}
+			}
+		})
+	}
+}
+
+func TestRingRW_Close(t *testing.T) {
+	t.Parallel()
+
+	rw := NewReadWriter(0, 8)
+	_, _ = rw.Write([]byte("abc"))
+
+	if err := rw.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if _, err := rw.Write([]byte("d")); !errors.Is(err, ErrClosed) {
+		t.Errorf("Write() error = %v, want %v", err, ErrClosed)
+	}
+	if _, err := rw.Read(make([]byte, 4)); err != io.EOF {
+		t.Errorf("Read() error = %v, want %v", err, io.EOF)
+	}
+}
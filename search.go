@@ -0,0 +1,56 @@
+package ringbuffer
+
+import "bytes"
+
+// FindAll returns the logical offsets of all the occurrences of sub in the
+// buffer content, in order, overlapping ones included. Occurrences across
+// the end and the beginning of the underlying buffer are found too.
+// It returns an empty slice if there are none, or if sub is empty.
+func (r *RingBuffer) FindAll(sub []byte) []int {
+	offsets := []int{}
+	if len(sub) == 0 {
+		return offsets
+	}
+
+	first, second := r.Segments()
+	offsets = appendIndexes(offsets, first, sub, 0)
+
+	// occurrences starting in the first segment and ending in the second
+	// can only be found in the last len(sub)-1 bytes of the former joined
+	// with the first len(sub)-1 bytes of the latter
+	if k := len(sub) - 1; k > 0 && len(second) > 0 {
+		lo := len(first) - k
+		if lo < 0 {
+			lo = 0
+		}
+		hi := k
+		if hi > len(second) {
+			hi = len(second)
+		}
+
+		window := make([]byte, 0, len(first)-lo+hi)
+		window = append(window, first[lo:]...)
+		window = append(window, second[:hi]...)
+
+		for _, i := range appendIndexes(nil, window, sub, lo) {
+			if i+len(sub) > len(first) {
+				offsets = append(offsets, i)
+			}
+		}
+	}
+
+	return appendIndexes(offsets, second, sub, len(first))
+}
+
+// appendIndexes appends to dst the indexes of all the occurrences of sub in
+// s, overlapping ones included, increased by base.
+func appendIndexes(dst []int, s, sub []byte, base int) []int {
+	for i := 0; ; {
+		j := bytes.Index(s[i:], sub)
+		if j < 0 {
+			return dst
+		}
+		dst = append(dst, base+i+j)
+		i += j + 1
+	}
+}
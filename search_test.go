@@ -0,0 +1,102 @@
+package ringbuffer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRingBuffer_FindAll(t *testing.T) {
+	t.Parallel()
+
+	// "fgab123"
+	wrapped := func() *RingBuffer {
+		return &RingBuffer{
+			buf:      []byte{'a', 'b', '1', '2', '3', 'f', 'g'},
+			pos:      5,
+			written:  17,
+			ringMode: true,
+			maxSize:  7,
+		}
+	}
+
+	tests := []struct {
+		name        string
+		inputBuffer *RingBuffer
+		sub         string
+		want        []int
+	}{
+		{
+			name:        "empty buffer",
+			inputBuffer: NewRingBuffer(0, 4),
+			sub:         "a",
+			want:        []int{},
+		},
+		{
+			name:        "empty sub",
+			inputBuffer: wrapped(),
+			sub:         "",
+			want:        []int{},
+		},
+		{
+			name:        "not found",
+			inputBuffer: wrapped(),
+			sub:         "zz",
+			want:        []int{},
+		},
+		{
+			name: "overlapping, no ring",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'a', 'a', 'b', 'a', 'a', 0},
+				pos:      6,
+				written:  6,
+				ringMode: false,
+				maxSize:  7,
+			},
+			sub:  "aa",
+			want: []int{0, 1, 4},
+		},
+		{
+			name:        "straddling the ring boundary",
+			inputBuffer: wrapped(),
+			sub:         "gab",
+			want:        []int{1},
+		},
+		{
+			name:        "whole content",
+			inputBuffer: wrapped(),
+			sub:         "fgab123",
+			want:        []int{0},
+		},
+		{
+			name: "overlapping across the ring boundary",
+			inputBuffer: &RingBuffer{
+				buf:      []byte{'a', 'a', 'x', 'a', 'a'},
+				pos:      3,
+				written:  8,
+				ringMode: true,
+				maxSize:  5,
+			},
+			sub:  "aa",
+			want: []int{0, 1, 2},
+		},
+		{
+			name:        "single byte",
+			inputBuffer: wrapped(),
+			sub:         "1",
+			want:        []int{4},
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := tt.inputBuffer.FindAll([]byte(tt.sub))
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindAll() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package ringbuffer
+
+// sourceSpan maps a range of the stream of bytes written into the buffer to
+// the source it has been read from with ReadFromAt.
+type sourceSpan struct {
+	offset int64 // offset in the stream of bytes written
+	src    int64 // offset in the source
+	n      int64
+}
+
+// WithSourceOffsets enables the tracking, for the content ingested with
+// ReadFromAt, of the offsets in the source the bytes have been read from,
+// exposed by SourceOffset. It is useful, e.g., to report where in a file a
+// match found in a sliding window comes from.
+func WithSourceOffsets() Option {
+	return func(r *RingBuffer) {
+		r.sourceOffsets = true
+	}
+}
+
+// SourceOffset returns the offset in the source of the byte at the logical
+// index i of the content, if it has been ingested by ReadFromAt with the
+// tracking enabled by WithSourceOffsets.
+// It returns false if i is out of the content, e.g. because the byte has
+// been evicted, or if the byte has been written by other methods.
+func (r *RingBuffer) SourceOffset(i int) (int64, bool) {
+	if i < 0 || i >= r.Len() {
+		return 0, false
+	}
+
+	offset := r.OldestOffset() + int64(i)
+	for _, s := range r.sources {
+		if offset >= s.offset && offset < s.offset+s.n {
+			return s.src + offset - s.offset, true
+		}
+	}
+	return 0, false
+}
+
+// trackSource records that the n bytes written from the offset of the
+// stream have been read from src, merging contiguous spans and forgetting
+// the evicted ones.
+func (r *RingBuffer) trackSource(offset, src, n int64) {
+	oldest := r.OldestOffset()
+	kept := r.sources[:0]
+	for _, s := range r.sources {
+		if s.offset+s.n > oldest {
+			kept = append(kept, s)
+		}
+	}
+	r.sources = kept
+
+	if last := len(r.sources) - 1; last >= 0 {
+		if s := &r.sources[last]; s.offset+s.n == offset && s.src+s.n == src {
+			s.n += n
+			return
+		}
+	}
+	r.sources = append(r.sources, sourceSpan{offset: offset, src: src, n: n})
+}
@@ -0,0 +1,111 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRingBuffer_SourceOffset(t *testing.T) {
+	t.Parallel()
+
+	source := bytes.NewReader([]byte("0123456789abcdefghij"))
+
+	type lookup struct {
+		i      int
+		want   int64
+		wantOk bool
+	}
+
+	tests := []struct {
+		name    string
+		maxSize int
+		ingest  func(r *RingBuffer)
+		lookups []lookup
+	}{
+		{
+			name:    "single region",
+			maxSize: 16,
+			ingest: func(r *RingBuffer) {
+				_, _ = r.ReadFromAt(source, 4, 6)
+			},
+			lookups: []lookup{
+				{i: 0, want: 4, wantOk: true},
+				{i: 5, want: 9, wantOk: true},
+				{i: 6, wantOk: false},
+				{i: -1, wantOk: false},
+			},
+		},
+		{
+			name:    "regions and plain writes",
+			maxSize: 16,
+			ingest: func(r *RingBuffer) {
+				_, _ = r.ReadFromAt(source, 10, 2)
+				_, _ = r.Write([]byte("--"))
+				_, _ = r.ReadFromAt(source, 0, 3)
+			},
+			lookups: []lookup{
+				{i: 1, want: 11, wantOk: true},
+				{i: 2, wantOk: false},
+				{i: 3, wantOk: false},
+				{i: 4, want: 0, wantOk: true},
+				{i: 6, want: 2, wantOk: true},
+			},
+		},
+		{
+			name:    "through a ring wrap",
+			maxSize: 8,
+			ingest: func(r *RingBuffer) {
+				_, _ = r.ReadFromAt(source, 0, 6)
+				_, _ = r.ReadFromAt(source, 12, 5)
+			},
+			lookups: []lookup{
+				// content is "345cdefg": the first 3 bytes are evicted
+				{i: 0, want: 3, wantOk: true},
+				{i: 2, want: 5, wantOk: true},
+				{i: 3, want: 12, wantOk: true},
+				{i: 7, want: 16, wantOk: true},
+				{i: 8, wantOk: false},
+			},
+		},
+		{
+			name:    "evicted mappings",
+			maxSize: 4,
+			ingest: func(r *RingBuffer) {
+				_, _ = r.ReadFromAt(source, 0, 4)
+				_, _ = r.Write([]byte("abcd"))
+			},
+			lookups: []lookup{
+				{i: 0, wantOk: false},
+				{i: 3, wantOk: false},
+			},
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(0, tt.maxSize, WithSourceOffsets())
+			tt.ingest(r)
+
+			for _, l := range tt.lookups {
+				got, ok := r.SourceOffset(l.i)
+				if ok != l.wantOk || (ok && got != l.want) {
+					t.Errorf("SourceOffset(%d) got = %d, %v, want %d, %v", l.i, got, ok, l.want, l.wantOk)
+				}
+			}
+		})
+	}
+}
+
+func TestRingBuffer_SourceOffset_disabled(t *testing.T) {
+	t.Parallel()
+
+	r := NewRingBuffer(0, 8)
+	_, _ = r.ReadFromAt(bytes.NewReader([]byte("abc")), 0, 3)
+
+	if _, ok := r.SourceOffset(0); ok {
+		t.Errorf("SourceOffset(0) got = true without WithSourceOffsets, want false")
+	}
+}
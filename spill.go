@@ -0,0 +1,110 @@
+package ringbuffer
+
+import (
+	"fmt"
+	"io"
+)
+
+// SpillPolicy defines what Write does when the spill destination set with
+// WithSpill returns an error.
+type SpillPolicy int
+
+const (
+	// SpillContinue goes on with the write, so the bytes that couldn't be
+	// spilled are lost. The error is kept and returned by SpillErr.
+	// It is the default policy.
+	SpillContinue SpillPolicy = iota
+
+	// SpillFail makes the Write fail with the spill error, leaving the
+	// buffer untouched. The destination could have received part of the
+	// evicted bytes anyway.
+	SpillFail
+)
+
+// WithSpill makes every Write copy the bytes it is going to overwrite into
+// w before overwriting them, so that w receives the complete history of
+// the writes while the buffer keeps the most recent part, e.g. with w being
+// a file. The bytes of a single write larger than the maximum size that
+// would never be stored are spilled too, in order.
+// w is called on the write path, so a *bufio.Writer is advisable for slow
+// destinations: Close flushes w if it has a Flush method, and closes it if
+// it is an io.Closer.
+// The content removed by Read, Drain, Reset, or by shrinking the maximum
+// size is not spilled, since it is not overwritten by a write.
+// policy defines what happens when w returns an error.
+func WithSpill(w io.Writer, policy SpillPolicy) Option {
+	return func(r *RingBuffer) {
+		r.spill = w
+		r.spillPolicy = policy
+	}
+}
+
+// SpillErr returns the last error returned by the spill destination with
+// the SpillContinue policy, or nil if there has been none.
+func (r *RingBuffer) SpillErr() error {
+	return r.spillErr
+}
+
+// spillEvicted writes into the spill destination the bytes that writing p
+// is going to overwrite: the oldest ones of the content, followed by the
+// beginning of p if it doesn't fit in the buffer.
+func (r *RingBuffer) spillEvicted(p []byte) error {
+	n := r.Len() + len(p) - r.maxSize
+	if n <= 0 {
+		return nil
+	}
+
+	first, second := r.Segments()
+
+	// only the spilled part of p is copied and handed to the destination,
+	// so that p itself doesn't escape to the heap on every Write
+	var head []byte
+	if stored := len(first) + len(second); n > stored {
+		head = append([]byte(nil), p[:n-stored]...)
+	}
+
+	for _, s := range [][]byte{first, second, head} {
+		if n == 0 {
+			break
+		}
+		if len(s) == 0 {
+			continue
+		}
+		if len(s) > n {
+			s = s[:n]
+		}
+		n -= len(s)
+
+		if _, err := r.spill.Write(s); err != nil {
+			err = fmt.Errorf("ringbuffer: spill: %w", err)
+			if r.spillPolicy == SpillFail {
+				return err
+			}
+			r.spillErr = err
+			return nil
+		}
+	}
+	return nil
+}
+
+// closeSpill flushes and closes the spill destination, if it supports it,
+// and detaches it from the buffer so that it is closed only once.
+func (r *RingBuffer) closeSpill() error {
+	w := r.spill
+	r.spill = nil
+
+	if f, ok := w.(interface{ Flush() error }); ok {
+		if err := f.Flush(); err != nil {
+			if c, ok := w.(io.Closer); ok {
+				_ = c.Close()
+			}
+			return fmt.Errorf("ringbuffer: spill: %w", err)
+		}
+	}
+	if c, ok := w.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			return fmt.Errorf("ringbuffer: spill: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package ringbuffer
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+func TestWithSpill(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		writes    []string
+		wantSpill string
+		wantRing  string
+	}{
+		{
+			name:      "no eviction",
+			writes:    []string{"abc", "def"},
+			wantSpill: "",
+			wantRing:  "abcdef",
+		},
+		{
+			name:      "wrapping",
+			writes:    []string{"abcdef", "ghij", "kl"},
+			wantSpill: "abcd",
+			wantRing:  "efghijkl",
+		},
+		{
+			name:      "write larger than the buffer",
+			writes:    []string{"abc", "0123456789"},
+			wantSpill: "abc01",
+			wantRing:  "23456789",
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var spill bytes.Buffer
+			r := NewRingBuffer(0, 8, WithSpill(&spill, SpillContinue))
+			for _, w := range tt.writes {
+				_, _ = r.Write([]byte(w))
+			}
+
+			if got := spill.String(); got != tt.wantSpill {
+				t.Errorf("spill got = %q, want %q", got, tt.wantSpill)
+			}
+			if got := r.String(); got != tt.wantRing {
+				t.Errorf("String() got = %q, want %q", got, tt.wantRing)
+			}
+		})
+	}
+}
+
+func TestWithSpill_manyWraps(t *testing.T) {
+	t.Parallel()
+
+	rnd := rand.New(rand.NewSource(1))
+
+	var spill, all bytes.Buffer
+	r := NewRingBuffer(0, 64, WithSpill(&spill, SpillContinue))
+	for i := 0; i < 1000; i++ {
+		p := make([]byte, rnd.Intn(100))
+		rnd.Read(p)
+		all.Write(p)
+
+		switch i % 3 {
+		case 0:
+			_, _ = r.Write(p)
+		case 1:
+			_, _ = r.WriteMulti(p[:len(p)/2], p[len(p)/2:])
+		case 2:
+			_, _ = r.WriteWith(len(p), func(dst []byte) int { return copy(dst, p) })
+		}
+	}
+
+	// the spilled bytes followed by the content are the whole history
+	got := append(spill.Bytes(), r.Bytes()...)
+	if !bytes.Equal(got, all.Bytes()) {
+		t.Errorf("spill + content differs from the written bytes, len %d, want %d", len(got), all.Len())
+	}
+}
+
+// failingWriter fails every write with err.
+type failingWriter struct {
+	err error
+}
+
+func (w failingWriter) Write(p []byte) (int, error) {
+	return 0, w.err
+}
+
+func TestWithSpill_errors(t *testing.T) {
+	t.Parallel()
+
+	errDisk := errors.New("disk full")
+
+	t.Run("continue", func(t *testing.T) {
+		t.Parallel()
+
+		r := NewRingBuffer(0, 4, WithSpill(failingWriter{errDisk}, SpillContinue))
+		_, _ = r.Write([]byte("abcd"))
+
+		if n, err := r.Write([]byte("ef")); n != 2 || err != nil {
+			t.Errorf("Write() got = %d, %v, want 2, nil", n, err)
+		}
+		if got := r.String(); got != "cdef" {
+			t.Errorf("String() got = %q, want %q", got, "cdef")
+		}
+		if err := r.SpillErr(); !errors.Is(err, errDisk) {
+			t.Errorf("SpillErr() got = %v, want %v", err, errDisk)
+		}
+	})
+
+	t.Run("fail", func(t *testing.T) {
+		t.Parallel()
+
+		r := NewRingBuffer(0, 4, WithSpill(failingWriter{errDisk}, SpillFail))
+		_, _ = r.Write([]byte("abcd"))
+
+		if n, err := r.Write([]byte("ef")); n != 0 || !errors.Is(err, errDisk) {
+			t.Errorf("Write() got = %d, %v, want 0, %v", n, err, errDisk)
+		}
+		if got := r.String(); got != "abcd" {
+			t.Errorf("String() got = %q, want %q", got, "abcd")
+		}
+		if err := r.SpillErr(); err != nil {
+			t.Errorf("SpillErr() got = %v, want nil", err)
+		}
+	})
+}
+
+// closingWriter records the calls to Flush and Close.
+type closingWriter struct {
+	bytes.Buffer
+	flushErr, closeErr error
+	flushed, closed    int
+}
+
+func (w *closingWriter) Flush() error {
+	w.flushed++
+	return w.flushErr
+}
+
+func (w *closingWriter) Close() error {
+	w.closed++
+	return w.closeErr
+}
+
+func TestRingBuffer_Close_spill(t *testing.T) {
+	t.Parallel()
+
+	t.Run("flush", func(t *testing.T) {
+		t.Parallel()
+
+		var dst bytes.Buffer
+		bw := bufio.NewWriter(&dst)
+		r := NewRingBuffer(0, 4, WithSpill(bw, SpillFail))
+		_, _ = r.Write([]byte("abcdef"))
+
+		if got := dst.String(); got != "" {
+			t.Errorf("spilled before Close got = %q, want %q", got, "")
+		}
+		if err := r.Close(); err != nil {
+			t.Errorf("Close() got = %v, want nil", err)
+		}
+		if got := dst.String(); got != "ab" {
+			t.Errorf("spilled after Close got = %q, want %q", got, "ab")
+		}
+	})
+
+	errDisk := errors.New("disk full")
+
+	tests := []struct {
+		name                    string
+		flushErr, closeErr      error
+		wantErr                 error
+		wantFlushed, wantClosed int
+	}{
+		{name: "no error", wantFlushed: 1, wantClosed: 1},
+		{name: "flush error", flushErr: errDisk, wantErr: errDisk, wantFlushed: 1, wantClosed: 1},
+		{name: "close error", closeErr: errDisk, wantErr: errDisk, wantFlushed: 1, wantClosed: 1},
+	}
+	for _, tt := range tests {
+		var tt = tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := &closingWriter{flushErr: tt.flushErr, closeErr: tt.closeErr}
+			r := NewRingBuffer(0, 4, WithSpill(w, SpillFail))
+
+			if err := r.Close(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Close() got = %v, want %v", err, tt.wantErr)
+			}
+			// a second Close doesn't touch the destination again
+			if err := r.Close(); err != nil {
+				t.Errorf("second Close() got = %v, want nil", err)
+			}
+			if w.flushed != tt.wantFlushed || w.closed != tt.wantClosed {
+				t.Errorf("Flush, Close calls got = %d, %d, want %d, %d", w.flushed, w.closed, tt.wantFlushed, tt.wantClosed)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_ResetFull_spill(t *testing.T) {
+	t.Parallel()
+
+	var dst bytes.Buffer
+	bw := bufio.NewWriter(&dst)
+	r := NewRingBuffer(0, 4, WithSpill(bw, SpillFail))
+	_, _ = r.Write([]byte("abcdef"))
+
+	if err := r.ResetFull(); err != nil {
+		t.Errorf("ResetFull() error = %v", err)
+	}
+	if got := dst.String(); got != "ab" {
+		t.Errorf("spilled after ResetFull got = %q, want %q", got, "ab")
+	}
+
+	errDisk := errors.New("disk full")
+	w := &closingWriter{closeErr: errDisk}
+	r = NewRingBuffer(0, 4, WithSpill(w, SpillFail))
+
+	if err := r.ResetFull(); !errors.Is(err, errDisk) {
+		t.Errorf("ResetFull() error = %v, want %v", err, errDisk)
+	}
+	if w.flushed != 1 || w.closed != 1 {
+		t.Errorf("Flush, Close calls got = %d, %d, want 1, 1", w.flushed, w.closed)
+	}
+	if _, err := r.Write([]byte("abcdef")); err != nil {
+		t.Errorf("Write() after ResetFull error = %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package ringbuffer
+
+// State is the stage of the lifecycle of a RingBuffer, as returned by
+// State.
+type State int
+
+const (
+	// StateEmpty is a buffer holding no content.
+	StateEmpty State = iota
+
+	// StateFilling is a buffer holding some content, with room for more
+	// before the maximum size.
+	StateFilling
+
+	// StateFull is a buffer whose content reached the maximum size, without
+	// having overwritten anything yet.
+	StateFull
+
+	// StateRing is a buffer that is overwriting its oldest content, i.e.
+	// that behaves like a ring buffer.
+	StateRing
+)
+
+// String returns the name of the state.
+func (s State) String() string {
+	switch s {
+	case StateEmpty:
+		return "empty"
+	case StateFilling:
+		return "filling"
+	case StateFull:
+		return "full"
+	case StateRing:
+		return "ring"
+	}
+	return "unknown"
+}
+
+// State returns the current stage of the lifecycle of the buffer.
+// A buffer with a maximum size of 0 is always empty.
+func (r *RingBuffer) State() State {
+	switch {
+	case r.Len() == 0:
+		return StateEmpty
+	case r.ringMode:
+		return StateRing
+	case r.Len() >= r.maxSize:
+		return StateFull
+	}
+	return StateFilling
+}
+
+// OnStateChange registers fn to be called on every transition of State,
+// with the previous and the new state, e.g. empty to filling on the first
+// write, full to ring on the first overwrite, ring to empty on Reset.
+// A single write can go through many states at once, e.g. from empty to
+// ring, and fn is called once, with the first and the last of them.
+// A nil fn removes the callback.
+func (r *RingBuffer) OnStateChange(fn func(from, to State)) {
+	r.onStateChange = fn
+	r.state = r.State()
+}
+
+// checkState calls the OnStateChange callback if the state has changed
+// since the previous check.
+func (r *RingBuffer) checkState() {
+	if r.onStateChange == nil {
+		return
+	}
+
+	if s := r.State(); s != r.state {
+		from := r.state
+		r.state = s
+		r.onStateChange(from, s)
+	}
+}
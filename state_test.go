@@ -0,0 +1,149 @@
+package ringbuffer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRingBuffer_OnStateChange(t *testing.T) {
+	t.Parallel()
+
+	type transition struct {
+		from, to State
+	}
+
+	tests := []struct {
+		name  string
+		steps func(r *RingBuffer)
+		want  []transition
+	}{
+		{
+			name: "full lifecycle",
+			steps: func(r *RingBuffer) {
+				_, _ = r.Write([]byte("ab"))
+				_, _ = r.Write([]byte("cd"))
+				_, _ = r.Write([]byte("ef"))
+				_, _ = r.Write([]byte("gh"))
+				_, _ = r.Write([]byte("i"))
+				_, _ = r.Write([]byte("j"))
+				r.Drain(2)
+				_, _ = r.Write([]byte("kl"))
+				r.Reset()
+			},
+			want: []transition{
+				{StateEmpty, StateFilling},
+				{StateFilling, StateFull},
+				{StateFull, StateRing},
+				{StateRing, StateFilling},
+				{StateFilling, StateFull},
+				{StateFull, StateEmpty},
+			},
+		},
+		{
+			name: "empty to ring in one write",
+			steps: func(r *RingBuffer) {
+				_, _ = r.Write([]byte("0123456789"))
+				_, _ = r.Write([]byte("a"))
+				r.Reset()
+			},
+			want: []transition{
+				{StateEmpty, StateRing},
+				{StateRing, StateEmpty},
+			},
+		},
+		{
+			name: "read to empty",
+			steps: func(r *RingBuffer) {
+				_, _ = r.Write([]byte("abc"))
+				_, _ = r.Read(make([]byte, 2))
+				_, _ = r.Read(make([]byte, 2))
+			},
+			want: []transition{
+				{StateEmpty, StateFilling},
+				{StateFilling, StateEmpty},
+			},
+		},
+		{
+			name: "maximum size grown",
+			steps: func(r *RingBuffer) {
+				_, _ = r.Write([]byte("abcdefghi"))
+				_ = r.SetMaxSize(16)
+				r.Reset()
+			},
+			want: []transition{
+				{StateEmpty, StateRing},
+				{StateRing, StateFilling},
+				{StateFilling, StateEmpty},
+			},
+		},
+		{
+			name: "write multi skipping bytes",
+			steps: func(r *RingBuffer) {
+				_, _ = r.WriteMulti([]byte("abcdefgh"), []byte("ijklmnop"))
+				r.Drain(2)
+			},
+			want: []transition{
+				{StateEmpty, StateFull},
+				{StateFull, StateRing},
+				{StateRing, StateFilling},
+			},
+		},
+		{
+			name: "close",
+			steps: func(r *RingBuffer) {
+				_, _ = r.Write([]byte("abcdefgh"))
+				_ = r.Close()
+			},
+			want: []transition{
+				{StateEmpty, StateFull},
+				{StateFull, StateEmpty},
+			},
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got []transition
+			r := NewRingBuffer(0, 8)
+			r.OnStateChange(func(from, to State) {
+				got = append(got, transition{from, to})
+			})
+
+			tt.steps(r)
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("OnStateChange() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_State(t *testing.T) {
+	t.Parallel()
+
+	r := NewRingBuffer(0, 4)
+	for _, step := range []struct {
+		write string
+		want  State
+	}{
+		{"", StateEmpty},
+		{"ab", StateFilling},
+		{"cd", StateFull},
+		{"e", StateRing},
+	} {
+		_, _ = r.Write([]byte(step.write))
+		if got := r.State(); got != step.want {
+			t.Errorf("State() after %q got = %v, want %v", step.write, got, step.want)
+		}
+	}
+
+	if got := NewRingBuffer(0, 0).State(); got != StateEmpty {
+		t.Errorf("State() with maxSize 0 got = %v, want %v", got, StateEmpty)
+	}
+	if got := State(42).String(); got != "unknown" {
+		t.Errorf("String() got = %q, want %q", got, "unknown")
+	}
+}
@@ -0,0 +1,119 @@
+package ringbuffer
+
+// Stats is a snapshot of the size and usage counters of a RingBuffer.
+type Stats struct {
+	// Cap is the size of memory allocated for the underlying buffer.
+	Cap int
+	// Len is the number of bytes of content currently held.
+	Len int
+	// Written is the number of bytes written so far.
+	Written int
+	// Dropped is the number of bytes written but no longer held, either
+	// overwritten or consumed.
+	Dropped int
+	// FillRatio is Len over the maximum size, in the range [0, 1].
+	FillRatio float64
+
+	// The following counters are collected only with WithDetailedStats,
+	// otherwise they are 0.
+
+	// EvictingWrites is the number of writes that overwrote some content.
+	EvictingWrites int
+	// MaxEviction is the largest number of bytes overwritten by one write.
+	MaxEviction int
+	// TotalEvicted is the number of bytes overwritten by the writes. The
+	// rest of Dropped has been consumed by the reads.
+	TotalEvicted int
+}
+
+// evictionStats counts the content overwritten by the writes.
+type evictionStats struct {
+	writes int
+	max    int
+	total  int
+}
+
+// WithDetailedStats enables the eviction counters of Stats, to tell how
+// often and how much the writes overwrite content not consumed yet, e.g. to
+// decide whether the maximum size should be increased.
+func WithDetailedStats() Option {
+	return func(r *RingBuffer) {
+		r.evictions = &evictionStats{}
+	}
+}
+
+// track records a write that overwrote n bytes.
+func (s *evictionStats) track(n int) {
+	if n <= 0 {
+		return
+	}
+	s.writes++
+	s.total += n
+	if n > s.max {
+		s.max = n
+	}
+}
+
+// Stats returns a snapshot of the buffer size and usage counters.
+func (r *RingBuffer) Stats() Stats {
+	s := Stats{
+		Cap:       r.Cap(),
+		Len:       r.Len(),
+		Written:   r.written,
+		Dropped:   r.written - r.Len(),
+		FillRatio: r.FillRatio(),
+	}
+	if r.evictions != nil {
+		s.EvictingWrites = r.evictions.writes
+		s.MaxEviction = r.evictions.max
+		s.TotalEvicted = r.evictions.total
+	}
+	return s
+}
+
+// FillRatio returns the length of the content over the maximum size, in the
+// range [0, 1]. It is 0 when the maximum size is 0.
+func (r *RingBuffer) FillRatio() float64 {
+	if r.maxSize <= 0 {
+		return 0
+	}
+	return float64(r.Len()) / float64(r.maxSize)
+}
+
+// fillBarGlyphs are the glyphs of a cell of FillBar, from empty to full in
+// eighths.
+var fillBarGlyphs = []rune("░▏▎▍▌▋▊▉█")
+
+// fillBarMarker marks, in the bar of FillBar, the position of the oldest
+// byte when the buffer is overwriting its content.
+const fillBarMarker = '┃'
+
+// FillBar renders FillRatio as a bar of width characters, using unicode
+// block glyphs with a resolution of an eighth of a character, e.g.
+// "████▌░░░" for a buffer 9/16 full.
+// In ring mode, when the buffer is full and the oldest content is being
+// overwritten, a marker shows where the oldest byte is in the underlying
+// buffer, i.e. where the writes are wrapping around.
+func (r *RingBuffer) FillBar(width int) string {
+	if width <= 0 {
+		return ""
+	}
+
+	bar := make([]rune, width)
+	eighths := int(r.FillRatio()*float64(width*8) + 0.5)
+	for i := range bar {
+		cell := eighths
+		if cell > 8 {
+			cell = 8
+		}
+		bar[i] = fillBarGlyphs[cell]
+		eighths -= cell
+	}
+
+	// a buffer with a maxSize of 0 is in ring mode after any write, with
+	// nothing to mark
+	if r.ringMode && r.maxSize > 0 {
+		bar[r.pos%r.maxSize*width/r.maxSize] = fillBarMarker
+	}
+	return string(bar)
+}
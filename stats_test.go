@@ -0,0 +1,205 @@
+package ringbuffer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRingBuffer_Stats(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		writes []string
+		drain  int
+		want   Stats
+	}{
+		{
+			name: "empty",
+			want: Stats{Cap: 2},
+		},
+		{
+			name:   "partially filled",
+			writes: []string{"abc"},
+			want:   Stats{Cap: 4, Len: 3, Written: 3, FillRatio: 0.375},
+		},
+		{
+			name:   "overwritten",
+			writes: []string{"abcdef", "ghijkl"},
+			want:   Stats{Cap: 8, Len: 8, Written: 12, Dropped: 4, FillRatio: 1},
+		},
+		{
+			name:   "drained",
+			writes: []string{"abcdef"},
+			drain:  2,
+			want:   Stats{Cap: 8, Len: 4, Written: 6, Dropped: 2, FillRatio: 0.5},
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(2, 8)
+			for _, w := range tt.writes {
+				_, _ = r.Write([]byte(w))
+			}
+			r.Drain(tt.drain)
+
+			if got := r.Stats(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Stats() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithDetailedStats(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		writes []string
+		drain  int
+		want   Stats
+	}{
+		{
+			name:   "no eviction",
+			writes: []string{"abc", "def"},
+			want:   Stats{Cap: 8, Len: 6, Written: 6, FillRatio: 0.75},
+		},
+		{
+			name:   "evictions of varying sizes",
+			writes: []string{"abcdef", "gh", "ijk", "l", "mnopqrstuvwxyz"},
+			want: Stats{
+				Cap: 8, Len: 8, Written: 26, Dropped: 18, FillRatio: 1,
+				EvictingWrites: 3, MaxEviction: 14, TotalEvicted: 18,
+			},
+		},
+		{
+			name:   "drained bytes are not evicted",
+			writes: []string{"abcdefgh", "ij"},
+			drain:  4,
+			want: Stats{
+				Cap: 8, Len: 4, Written: 10, Dropped: 6, FillRatio: 0.5,
+				EvictingWrites: 1, MaxEviction: 2, TotalEvicted: 2,
+			},
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(2, 8, WithDetailedStats())
+			for _, w := range tt.writes {
+				_, _ = r.Write([]byte(w))
+			}
+			r.Drain(tt.drain)
+
+			if got := r.Stats(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Stats() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithDetailedStats_WriteMulti(t *testing.T) {
+	t.Parallel()
+
+	r := NewRingBuffer(4, 4, WithDetailedStats())
+	_, _ = r.WriteMulti([]byte("abc"), []byte("de"), []byte("fgh"))
+
+	want := Stats{
+		Cap: 4, Len: 4, Written: 8, Dropped: 4, FillRatio: 1,
+		EvictingWrites: 2, MaxEviction: 3, TotalEvicted: 4,
+	}
+	if got := r.Stats(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Stats() got = %+v, want %+v", got, want)
+	}
+
+	r.ResetStats()
+	want = Stats{Cap: 4, Len: 4, Written: 4, FillRatio: 1}
+	if got := r.Stats(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Stats() after ResetStats got = %+v, want %+v", got, want)
+	}
+}
+
+func TestRingBuffer_FillBar(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		writes []string
+		width  int
+		want   string
+	}{
+		{
+			name:  "empty",
+			width: 8,
+			want:  "░░░░░░░░",
+		},
+		{
+			name:   "half",
+			writes: []string{"abcdefgh"},
+			width:  8,
+			want:   "████░░░░",
+		},
+		{
+			name:   "partial cell",
+			writes: []string{"abcdefghi"},
+			width:  8,
+			want:   "████▌░░░",
+		},
+		{
+			name:   "full, not overwriting",
+			writes: []string{"0123456789abcdef"},
+			width:  4,
+			want:   "████",
+		},
+		{
+			name:   "ring mode",
+			writes: []string{"0123456789abcdef", "ghij"},
+			width:  8,
+			want:   "██┃█████",
+		},
+		{
+			name:   "zero width",
+			writes: []string{"abc"},
+			width:  0,
+			want:   "",
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(0, 16)
+			for _, w := range tt.writes {
+				_, _ = r.Write([]byte(w))
+			}
+
+			got := r.FillBar(tt.width)
+			if got != tt.want {
+				t.Errorf("FillBar() got = %q, want %q", got, tt.want)
+			}
+			if n := len([]rune(got)); n != tt.width {
+				t.Errorf("FillBar() length got = %d, want %d", n, tt.width)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_FillBar_zeroMaxSize(t *testing.T) {
+	t.Parallel()
+
+	r := NewRingBuffer(0, 0)
+	_, _ = r.Write([]byte("abc"))
+
+	if got, want := r.FillBar(4), "░░░░"; got != want {
+		t.Errorf("FillBar() got = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,32 @@
+package ringbuffer
+
+// OnDrainBelow registers fn to be called when the length of the content
+// drops below threshold after having reached it, e.g. to signal that a
+// consumer caught up with a buffer that was filling up.
+// The callback is edge-triggered: it is called once on every downward
+// crossing, by Read, Drain, Reset and any other method removing content,
+// and not again until the length reaches the threshold once more.
+// A nil fn removes the callback.
+func (r *RingBuffer) OnDrainBelow(threshold int, fn func()) {
+	r.drainThreshold = threshold
+	r.onDrainBelow = fn
+	r.aboveThreshold = false
+	r.checkDrainBelow()
+}
+
+// checkDrainBelow tracks the length of the content against the threshold
+// of OnDrainBelow, calling the callback on a downward crossing.
+func (r *RingBuffer) checkDrainBelow() {
+	if r.onDrainBelow == nil {
+		return
+	}
+
+	if r.Len() >= r.drainThreshold {
+		r.aboveThreshold = true
+		return
+	}
+	if r.aboveThreshold {
+		r.aboveThreshold = false
+		r.onDrainBelow()
+	}
+}
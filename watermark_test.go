@@ -0,0 +1,80 @@
+package ringbuffer
+
+import "testing"
+
+func TestRingBuffer_OnDrainBelow(t *testing.T) {
+	t.Parallel()
+
+	type step struct {
+		name      string
+		do        func(r *RingBuffer)
+		wantCalls int
+	}
+
+	write := func(s string) func(r *RingBuffer) {
+		return func(r *RingBuffer) { _, _ = r.Write([]byte(s)) }
+	}
+	drain := func(n int) func(r *RingBuffer) {
+		return func(r *RingBuffer) { r.Drain(n) }
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "never above",
+			steps: []step{
+				{name: "write below", do: write("ab"), wantCalls: 0},
+				{name: "drain", do: drain(1), wantCalls: 0},
+			},
+		},
+		{
+			name: "downward crossings",
+			steps: []step{
+				{name: "reach threshold", do: write("abcd"), wantCalls: 0},
+				{name: "still at threshold", do: drain(0), wantCalls: 0},
+				{name: "cross down", do: drain(1), wantCalls: 1},
+				{name: "stay below", do: drain(1), wantCalls: 1},
+				{name: "cross up", do: write("xyz"), wantCalls: 1},
+				{name: "cross down by read", do: func(r *RingBuffer) { _, _ = r.Read(make([]byte, 3)) }, wantCalls: 2},
+				{name: "write below", do: write("a"), wantCalls: 2},
+				{name: "cross up again", do: write("bcdefgh"), wantCalls: 2},
+				{name: "cross down by reset", do: func(r *RingBuffer) { r.Reset() }, wantCalls: 3},
+				{name: "reset below", do: func(r *RingBuffer) { r.Reset() }, wantCalls: 3},
+			},
+		},
+		{
+			name: "crossing down by shrinking",
+			steps: []step{
+				{name: "fill", do: write("abcdefgh"), wantCalls: 0},
+				{name: "shrink", do: func(r *RingBuffer) { _ = r.SetMaxSize(2) }, wantCalls: 1},
+			},
+		},
+		{
+			name: "crossing down by closing",
+			steps: []step{
+				{name: "fill", do: write("abcdefgh"), wantCalls: 0},
+				{name: "close", do: func(r *RingBuffer) { _ = r.Close() }, wantCalls: 1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			calls := 0
+			r := NewRingBuffer(0, 8)
+			r.OnDrainBelow(4, func() { calls++ })
+
+			for _, s := range tt.steps {
+				s.do(r)
+				if calls != s.wantCalls {
+					t.Errorf("%s: calls got = %d, want %d", s.name, calls, s.wantCalls)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package ringbuffer
+
+import "math/bits"
+
+// sizeBuckets is the number of buckets of a sizeHistogram: the bucket i
+// counts the sizes n with bits.Len(n) == i.
+const sizeBuckets = 64
+
+// sizeHistogram counts sizes in buckets of exponentially growing width.
+type sizeHistogram [sizeBuckets]uint64
+
+// sizeHistograms are the histograms recorded with WithWriteSizeHistogram.
+type sizeHistograms struct {
+	// the size of every write
+	writes sizeHistogram
+	// the length of the content after every write
+	lengths sizeHistogram
+}
+
+// SizeBucket is a bucket of the histogram returned by WriteSizeHistogram,
+// counting the writes with a size in the range [Min, Max].
+type SizeBucket struct {
+	Min   int
+	Max   int
+	Count uint64
+}
+
+// WithWriteSizeHistogram enables the recording of the size of every Write,
+// in buckets of exponentially growing width, returned by
+// WriteSizeHistogram, and of the length reached by the content, used by
+// RecommendedInitialSize, e.g. to choose the initial and maximum size for a
+// workload.
+func WithWriteSizeHistogram() Option {
+	return func(r *RingBuffer) {
+		r.sizes = &sizeHistograms{}
+	}
+}
+
+// WriteSizeHistogram returns the non-empty buckets of the histogram of the
+// write sizes, from the smallest sizes: the first bucket holds the empty
+// writes, then every bucket doubles the range of the previous one, i.e.
+// [1, 1], [2, 3], [4, 7] and so on.
+// It returns nil if the recording has not been enabled with
+// WithWriteSizeHistogram.
+func (r *RingBuffer) WriteSizeHistogram() []SizeBucket {
+	if r.sizes == nil {
+		return nil
+	}
+
+	var out []SizeBucket
+	for i, count := range r.sizes.writes {
+		if count == 0 {
+			continue
+		}
+		out = append(out, SizeBucket{
+			Min:   bucketMin(i),
+			Max:   bucketMax(i),
+			Count: count,
+		})
+	}
+	return out
+}
+
+// RecommendedInitialSize suggests an initial size for buffers running the
+// same workload, so that they rarely need to grow: the 95th percentile of
+// the length reached by the content after each write, rounded up to the
+// upper bound of its bucket and limited to the maximum size.
+// It is meaningful only with WithWriteSizeHistogram, after running a
+// representative workload; otherwise, or if nothing has been written, it
+// returns 0.
+func (r *RingBuffer) RecommendedInitialSize() int {
+	if r.sizes == nil {
+		return 0
+	}
+
+	i := quantileBucket(r.sizes.lengths[:], 0.95)
+	if i < 0 {
+		return 0
+	}
+	if size := bucketMax(i); size < r.maxSize {
+		return size
+	}
+	return r.maxSize
+}
+
+// record adds the size n to the histogram.
+func (h *sizeHistogram) record(n int) {
+	h[bits.Len(uint(n))]++
+}
+
+// bucketMin returns the lowest size counted by the bucket i.
+func bucketMin(i int) int {
+	if i == 0 {
+		return 0
+	}
+	return 1 << uint(i-1)
+}
+
+// bucketMax returns the highest size counted by the bucket i.
+func bucketMax(i int) int {
+	if i == 0 {
+		return 0
+	}
+	return 1<<uint(i) - 1
+}
+
+// quantileBucket returns the index of the bucket of counts holding the q-th
+// quantile of the counted values, or -1 if counts are all 0.
+func quantileBucket(counts []uint64, q float64) int {
+	var total uint64
+	for _, c := range counts {
+		total += c
+	}
+	if total == 0 {
+		return -1
+	}
+
+	rank := uint64(q*float64(total) + 0.5)
+	if rank == 0 {
+		rank = 1
+	}
+
+	var seen uint64
+	for i, c := range counts {
+		seen += c
+		if seen >= rank {
+			return i
+		}
+	}
+	return len(counts) - 1
+}
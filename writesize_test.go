@@ -0,0 +1,154 @@
+package ringbuffer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWithWriteSizeHistogram(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		sizes []int
+		want  []SizeBucket
+	}{
+		{
+			name:  "no writes",
+			sizes: nil,
+			want:  nil,
+		},
+		{
+			name:  "small and large writes",
+			sizes: []int{0, 1, 3, 2, 3, 100, 127, 64, 5000},
+			want: []SizeBucket{
+				{Min: 0, Max: 0, Count: 1},
+				{Min: 1, Max: 1, Count: 1},
+				{Min: 2, Max: 3, Count: 3},
+				{Min: 64, Max: 127, Count: 3},
+				{Min: 4096, Max: 8191, Count: 1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(0, 1024, WithWriteSizeHistogram())
+			for _, size := range tt.sizes {
+				_, _ = r.Write(make([]byte, size))
+			}
+
+			if got := r.WriteSizeHistogram(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("WriteSizeHistogram() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithWriteSizeHistogram_multi(t *testing.T) {
+	t.Parallel()
+
+	r := NewRingBuffer(0, 8, WithWriteSizeHistogram())
+	_, _ = r.WriteMulti([]byte("ab"), []byte("0123456789"))
+	_, _ = r.WriteWith(4, func(dst []byte) int { return copy(dst, "xyz") })
+
+	want := []SizeBucket{
+		{Min: 2, Max: 3, Count: 2},
+		{Min: 8, Max: 15, Count: 1},
+	}
+	if got := r.WriteSizeHistogram(); !reflect.DeepEqual(got, want) {
+		t.Errorf("WriteSizeHistogram() got = %+v, want %+v", got, want)
+	}
+
+	if got := NewRingBuffer(0, 8).WriteSizeHistogram(); got != nil {
+		t.Errorf("WriteSizeHistogram() without recording got = %+v, want nil", got)
+	}
+}
+
+func TestRingBuffer_RecommendedInitialSize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		maxSize int
+		writes  []int
+		drain   bool
+		opts    []Option
+		wantMin int
+		wantMax int
+	}{
+		{
+			name:    "not instrumented",
+			maxSize: 1024,
+			writes:  []int{10, 10},
+			wantMin: 0,
+			wantMax: 0,
+		},
+		{
+			name:    "no writes",
+			maxSize: 1024,
+			opts:    []Option{WithWriteSizeHistogram()},
+			wantMin: 0,
+			wantMax: 0,
+		},
+		{
+			name:    "growing content",
+			maxSize: 1024,
+			// the content reaches 10, 20, ... 200 bytes
+			writes:  repeatInt(10, 20),
+			opts:    []Option{WithWriteSizeHistogram()},
+			wantMin: 190,
+			wantMax: 255,
+		},
+		{
+			name:    "drained content",
+			maxSize: 1024,
+			// the content never exceeds 30 bytes
+			writes:  repeatInt(30, 100),
+			drain:   true,
+			opts:    []Option{WithWriteSizeHistogram()},
+			wantMin: 30,
+			wantMax: 31,
+		},
+		{
+			name:    "limited to the maximum size",
+			maxSize: 100,
+			writes:  repeatInt(50, 20),
+			opts:    []Option{WithWriteSizeHistogram()},
+			wantMin: 100,
+			wantMax: 100,
+		},
+	}
+	for _, tt := range tests {
+		var tt = tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRingBuffer(0, tt.maxSize, tt.opts...)
+			for _, size := range tt.writes {
+				_, _ = r.Write(make([]byte, size))
+				if tt.drain {
+					r.Drain(size)
+				}
+			}
+
+			got := r.RecommendedInitialSize()
+			if got < tt.wantMin || got > tt.wantMax {
+				t.Errorf("RecommendedInitialSize() got = %d, want in [%d, %d]", got, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+// repeatInt returns a slice with n copies of v.
+func repeatInt(v, n int) []int {
+	out := make([]int, n)
+	for i := range out {
+		out[i] = v
+	}
+	return out
+}